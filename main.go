@@ -3,8 +3,11 @@ package main
 import (
 	"embed"
 	"flag"
+	"io/fs"
+	"os"
 
 	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/cli"
 	logs "github.com/Notifiarr/toolbarr/pkg/logs"
 	"github.com/Notifiarr/toolbarr/pkg/mnd"
 	"github.com/Notifiarr/toolbarr/pkg/starrs"
@@ -28,8 +31,15 @@ func main() {
 	var configFile string
 
 	flag.StringVar(&configFile, "c", "", "Config file path. Determined automatically if not provided.")
+	flag.Usage = func() { cli.Usage(flag.CommandLine.Output()) }
 	flag.Parse()
 
+	// Any arguments left over are a command to run without a window.
+	if flag.NArg() > 0 {
+		dist, _ := fs.Sub(assets, "frontend/dist")
+		os.Exit(cli.Run(log, configFile, dist, flag.Args())) //nolint:gocritic // no defers need to run.
+	}
+
 	appMenu := menu.NewMenu()
 	fileMenu := appMenu.AddSubmenu("File")
 	appMenu.Append(menu.EditMenu())
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
@@ -13,7 +14,6 @@ import (
 	"github.com/Notifiarr/toolbarr/pkg/mnd"
 	"github.com/Notifiarr/toolbarr/pkg/translations"
 	"github.com/gorilla/schema"
-	wr "github.com/wailsapp/wails/v2/pkg/runtime"
 	"golift.io/version"
 )
 
@@ -81,20 +81,7 @@ func (a *App) Version() Version {
 // Ask the user a Yes/No question.
 func (a *App) Ask(title, msg string) bool {
 	a.log.Tracef("Call:Ask(%s,%s)", title, msg)
-
-	resp, err := wr.MessageDialog(a.ctx, wr.MessageDialogOptions{
-		Type:          wr.QuestionDialog,
-		Title:         title,
-		Message:       msg,
-		Buttons:       []string{"Yes", "No"},
-		DefaultButton: "Yes",
-		CancelButton:  "No",
-	})
-	if err != nil {
-		a.log.Errorf("Dialog Failed: %v", err)
-	}
-
-	return resp == "Yes"
+	return a.host.Ask(title, msg)
 }
 
 // GetConfig returns a copy of the app settings.
@@ -117,17 +104,16 @@ func (a *App) SaveConfigItem(name string, value any, reload bool) (*ConfigSaved,
 	err := decoder.Decode(config, map[string][]string{name: {fmt.Sprint(value)}})
 	if err != nil {
 		a.log.Errorf("Writing config: decoding '%s' value '%v' error: %v", name, value, err)
-		return nil, fmt.Errorf(a.log.Translate("Writing config: decoding '%s' value '%v' error: %v", name, value, err))
+		return nil, errors.New(a.log.Translate("Writing config: decoding '%s' value '%v' error: %v", name, value, err))
 	}
 
 	if _, err = a.config.Write(config); err != nil {
 		a.log.Errorf("Error writing config: %v", err.Error())
-		return nil, fmt.Errorf(a.log.Translate("Error writing config: %v", err.Error()))
+		return nil, errors.New(a.log.Translate("Error writing config: %v", err.Error()))
 	}
 
 	if reload {
-		_ = a.log.Close()
-		a.log.Setup(a.ctx, a.config.Settings().LogConfig)
+		a.host.SetupLogs(a.config.Settings().LogConfig)
 	}
 
 	msg := a.log.Translate("Saved: '%s' Value: %v", name, value)
@@ -140,15 +126,14 @@ func (a *App) SaveConfigItem(name string, value any, reload bool) (*ConfigSaved,
 func (a *App) PickFolder(path string) (string, error) {
 	a.log.Tracef("Call:PickFolder(%s)", path)
 
-	dir, err := wr.OpenDirectoryDialog(a.ctx, wr.OpenDialogOptions{
-		DefaultDirectory:     a.fixFolderPath(path),
-		Title:                a.log.Translate("Choose Folder"),
-		CanCreateDirectories: true,
-		ShowHiddenFiles:      true,
+	dir, err := a.host.OpenDirectory(&mnd.FileDialog{
+		Directory: a.fixFolderPath(path),
+		Title:     a.log.Translate("Choose Folder"),
+		Hidden:    true,
 	})
 	if err != nil {
-		wr.LogError(a.ctx, err.Error())
-		return "", fmt.Errorf(a.log.Translate("Opening directory browser: %v", err))
+		a.host.LogError(err.Error())
+		return "", errors.New(a.log.Translate("Opening directory browser: %v", err))
 	}
 
 	return dir, nil
@@ -158,18 +143,15 @@ func (a *App) PickFolder(path string) (string, error) {
 func (a *App) PickFile(path, extname, extensions string) (string, error) {
 	a.log.Tracef("Call:PickFile(%s,%s,%s)", path, extname, extensions)
 
-	dir, err := wr.OpenFileDialog(a.ctx, wr.OpenDialogOptions{
-		DefaultDirectory: a.fixFolderPath(path),
-		Title:            a.log.Translate("Choose File"),
-		ShowHiddenFiles:  true,
-		Filters: []wr.FileFilter{{
-			DisplayName: extname,
-			Pattern:     extensions,
-		}},
+	dir, err := a.host.OpenFile(&mnd.FileDialog{
+		Directory: a.fixFolderPath(path),
+		Title:     a.log.Translate("Choose File"),
+		Hidden:    true,
+		Filters:   []mnd.FileFilter{{DisplayName: extname, Pattern: extensions}},
 	})
 	if err != nil {
-		wr.LogError(a.ctx, err.Error())
-		return "", fmt.Errorf(a.log.Translate("Opening file browser: %v", err))
+		a.host.LogError(err.Error())
+		return "", errors.New(a.log.Translate("Opening file browser: %v", err))
 	}
 
 	return dir, nil
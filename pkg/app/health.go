@@ -0,0 +1,9 @@
+package app
+
+import "github.com/Notifiarr/toolbarr/pkg/starrs"
+
+// HealthAll returns the health of every configured instance. Used on the landing page.
+func (a *App) HealthAll() *starrs.HealthMatrix {
+	a.log.Tracef("Call:HealthAll()")
+	return a.Starrs.HealthAll(a.config.Settings().Instances)
+}
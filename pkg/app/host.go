@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	wr "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Host is the window the app runs in. The starrs package only needs mnd.App.
+// The wails runtime provides the default Host. The web server provides another.
+type Host interface {
+	mnd.App
+	// ErrorDialog displays an error.
+	ErrorDialog(title, msg string)
+	// OpenDirectory asks for a folder. An empty path means the user canceled.
+	OpenDirectory(dialog *mnd.FileDialog) (string, error)
+	// SetupLogs applies a changed log config.
+	SetupLogs(config logs.LogConfig)
+}
+
+// host implements Host with the wails runtime.
+// This is kept off the App struct, so these methods are not bound to the frontend.
+type host struct {
+	ctx context.Context
+	log *logs.Logger
+}
+
+var _ Host = (*host)(nil)
+
+// Ask the user a Yes/No question.
+func (h *host) Ask(title, msg string) bool {
+	resp, err := wr.MessageDialog(h.ctx, wr.MessageDialogOptions{
+		Type:          wr.QuestionDialog,
+		Title:         title,
+		Message:       msg,
+		Buttons:       []string{"Yes", "No"},
+		DefaultButton: "Yes",
+		CancelButton:  "No",
+	})
+	if err != nil {
+		h.log.Errorf("Dialog Failed: %v", err)
+	}
+
+	return resp == "Yes"
+}
+
+// Emit sends an event to the frontend.
+func (h *host) Emit(event string, data any) {
+	wr.EventsEmit(h.ctx, event, data)
+}
+
+// OpenFile opens the file selector.
+func (h *host) OpenFile(dialog *mnd.FileDialog) (string, error) {
+	return wr.OpenFileDialog(h.ctx, wr.OpenDialogOptions{ //nolint:wrapcheck
+		DefaultDirectory: dialog.Directory,
+		DefaultFilename:  dialog.Filename,
+		Title:            dialog.Title,
+		ShowHiddenFiles:  dialog.Hidden,
+		Filters:          fileFilters(dialog.Filters),
+	})
+}
+
+// OpenDirectory opens the folder selector.
+func (h *host) OpenDirectory(dialog *mnd.FileDialog) (string, error) {
+	return wr.OpenDirectoryDialog(h.ctx, wr.OpenDialogOptions{ //nolint:wrapcheck
+		DefaultDirectory:     dialog.Directory,
+		Title:                dialog.Title,
+		ShowHiddenFiles:      dialog.Hidden,
+		CanCreateDirectories: true,
+	})
+}
+
+// SaveFile opens the file save dialog.
+func (h *host) SaveFile(dialog *mnd.FileDialog) (string, error) {
+	return wr.SaveFileDialog(h.ctx, wr.SaveDialogOptions{ //nolint:wrapcheck
+		DefaultDirectory:     dialog.Directory,
+		DefaultFilename:      dialog.Filename,
+		Title:                dialog.Title,
+		ShowHiddenFiles:      dialog.Hidden,
+		Filters:              fileFilters(dialog.Filters),
+		CanCreateDirectories: true,
+	})
+}
+
+// ErrorDialog displays an error on screen.
+func (h *host) ErrorDialog(title, msg string) {
+	_, _ = wr.MessageDialog(h.ctx, wr.MessageDialogOptions{
+		Type:    wr.ErrorDialog,
+		Title:   title,
+		Message: msg,
+	})
+}
+
+// SetupLogs reopens the log file and sets the wails log level.
+func (h *host) SetupLogs(config logs.LogConfig) {
+	_ = h.log.Close()
+	h.log.Setup(h.ctx, config)
+}
+
+// LogError sends an error to the wails log.
+func (h *host) LogError(msg string) {
+	wr.LogError(h.ctx, msg)
+}
+
+func fileFilters(filters []mnd.FileFilter) []wr.FileFilter {
+	output := make([]wr.FileFilter, len(filters))
+	for idx, filter := range filters {
+		output[idx] = wr.FileFilter{DisplayName: filter.DisplayName, Pattern: filter.Pattern}
+	}
+
+	return output
+}
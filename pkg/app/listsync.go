@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+// listHistoryFile is stored next to the config file.
+const listHistoryFile = "listsync.json"
+
+// ListSyncHistory is the import list sync history, and the totals for each list.
+type ListSyncHistory struct {
+	Msg     string
+	History []*starrs.ListSync
+	Totals  []*starrs.ListSyncTotal
+}
+
+// SyncImportLists syncs one import list, or every list in the instance with a listID of 0,
+// and records the results in the history.
+func (a *App) SyncImportLists(config *starrs.AppConfig, listID int64) (*ListSyncHistory, error) {
+	a.log.Tracef("Call:SyncImportLists(%s, %s, %d)", config.App, config.Name, listID)
+
+	results, err := a.Starrs.SyncImportLists(config, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.addListHistory(results)
+}
+
+// SyncImportListsAll syncs every import list in every configured instance, and records the results in the history.
+func (a *App) SyncImportListsAll() (*ListSyncHistory, error) {
+	a.log.Tracef("Call:SyncImportListsAll()")
+	return a.addListHistory(a.Starrs.SyncImportListsAll(a.config.Settings().Instances))
+}
+
+// ListSyncHistory returns the import list sync history, and the totals for each list.
+func (a *App) ListSyncHistory() (*ListSyncHistory, error) {
+	a.log.Tracef("Call:ListSyncHistory()")
+
+	a.history.Lock()
+	defer a.history.Unlock()
+
+	history, err := starrs.ReadListHistory(a.listHistoryPath())
+	if err != nil {
+		msg := a.log.Translate("Reading import list sync history: %v", err.Error())
+		a.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &ListSyncHistory{
+		Msg:     a.log.Translate("Found %d import list syncs.", len(history)),
+		History: history,
+		Totals:  starrs.ListHistoryTotals(history),
+	}, nil
+}
+
+func (a *App) addListHistory(results []*starrs.ListSync) (*ListSyncHistory, error) {
+	a.history.Lock()
+	defer a.history.Unlock()
+
+	history, err := starrs.AddListHistory(a.listHistoryPath(), results)
+	if err != nil {
+		msg := a.log.Translate("Saving import list sync history: %v", err.Error())
+		a.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	added, failed := 0, 0
+
+	for _, result := range results {
+		added += result.Added
+
+		if result.Failed {
+			failed++
+		}
+	}
+
+	return &ListSyncHistory{
+		Msg: a.log.Translate("Synced %d import lists; %d failed. They added %d items.",
+			len(results), failed, added),
+		History: history,
+		Totals:  starrs.ListHistoryTotals(history),
+	}, nil
+}
+
+func (a *App) listHistoryPath() string {
+	return filepath.Join(filepath.Dir(a.config.Settings().File), listHistoryFile)
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+// SavedReports is the response to the frontend after saved reports change.
+type SavedReports struct {
+	Msg  string
+	List []starrs.Report
+}
+
+// Reports returns the built-in reports for an app, followed by the user's saved queries for it.
+// Saved queries without an app are included for every app.
+func (a *App) Reports(starrApp string) []*starrs.Report {
+	a.log.Tracef("Call:Reports(%s)", starrApp)
+
+	reports := starrs.BuiltinReports(starrApp)
+
+	for _, report := range a.config.Settings().Reports {
+		if report.App == "" || report.App == starrApp {
+			reports = append(reports, &report)
+		}
+	}
+
+	return reports
+}
+
+// SaveReport adds or replaces a saved query. Queries are matched by name.
+func (a *App) SaveReport(report starrs.Report) (*SavedReports, error) {
+	a.log.Tracef("Call:SaveReport(%s, %s)", report.Name, report.App)
+
+	report.Name = strings.TrimSpace(report.Name)
+	if report.Name == "" || strings.TrimSpace(report.SQL) == "" {
+		return nil, errors.New(a.log.Translate("A saved query needs a name and a query."))
+	}
+
+	for _, builtin := range starrs.BuiltinReports(report.App) {
+		if strings.EqualFold(builtin.Name, report.Name) {
+			return nil, errors.New(a.log.Translate("%s is a built-in report. Pick another name.", report.Name))
+		}
+	}
+
+	report.Builtin = false
+	msg := a.log.Translate("Added saved query %s.", report.Name)
+	settings := a.config.Settings()
+	idx := a.findReport(settings.Reports, report.Name)
+
+	if idx < 0 {
+		settings.Reports = append(settings.Reports, report)
+	} else {
+		settings.Reports[idx] = report
+		msg = a.log.Translate("Updated saved query %s.", report.Name)
+	}
+
+	settings, err := a.config.Write(settings)
+	if err != nil {
+		return nil, errors.New(a.log.Translate("Error writing config: %v", err.Error()))
+	}
+
+	return &SavedReports{Msg: msg, List: settings.Reports}, nil
+}
+
+// RemoveReport deletes a saved query.
+func (a *App) RemoveReport(name string) (*SavedReports, error) {
+	a.log.Tracef("Call:RemoveReport(%s)", name)
+
+	settings := a.config.Settings()
+
+	idx := a.findReport(settings.Reports, name)
+	if idx < 0 {
+		return nil, errors.New(a.log.Translate("Saved query %s does not exist.", name))
+	}
+
+	question := a.log.Translate("Really delete saved query? Name: %s", name)
+	if !a.Ask(a.log.Translate("Remove Saved Query"), question) {
+		return &SavedReports{}, nil
+	}
+
+	settings.Reports = append(settings.Reports[:idx], settings.Reports[idx+1:]...)
+
+	settings, err := a.config.Write(settings)
+	if err != nil {
+		return nil, errors.New(a.log.Translate("Error writing config: %v", err.Error()))
+	}
+
+	return &SavedReports{
+		Msg:  a.log.Translate("Removed saved query %s.", name),
+		List: settings.Reports,
+	}, nil
+}
+
+// findReport returns the index of a saved query, or -1.
+func (a *App) findReport(reports []starrs.Report, name string) int {
+	for idx := range reports {
+		if strings.EqualFold(reports[idx].Name, name) {
+			return idx
+		}
+	}
+
+	return -1
+}
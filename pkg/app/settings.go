@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/config"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+)
+
+// ExportSettings saves the settings to a json file the user picks.
+// Instance passwords and API keys are removed when strip is true.
+func (a *App) ExportSettings(strip bool) (string, error) {
+	a.log.Tracef("Call:ExportSettings(%v)", strip)
+
+	filePath, err := a.host.SaveFile(&mnd.FileDialog{
+		Directory: a.fixFolderPath(""),
+		Filename:  fmt.Sprintf("%s-settings-%s.json", mnd.Name, time.Now().Format("2006-01-02")),
+		Title:     a.log.Translate("Export Settings"),
+		Filters:   mnd.JSONFilter(),
+	})
+	if err != nil {
+		a.host.LogError(err.Error())
+		return "", errors.New(a.log.Translate("Opening file browser: %v", err))
+	} else if filePath == "" {
+		return "", nil
+	}
+
+	fileOpen, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mnd.Mode0600)
+	if err != nil {
+		return "", errors.New(a.log.Translate("Opening file: %v", err))
+	}
+	defer fileOpen.Close()
+
+	if err = config.ExportJSON(fileOpen, a.config.Settings(), strip); err != nil {
+		return "", errors.New(a.log.Translate("Encoding and writing file: %v", err))
+	}
+
+	msg := a.log.Translate("Exported settings to %s", filePath)
+	a.log.Infof(msg)
+
+	return msg, nil
+}
+
+// ImportSettings reads a json settings export and merges its instances into the running config.
+// Instances with the same app and name are replaced. Other settings are not imported.
+func (a *App) ImportSettings() (*config.Settings, error) {
+	a.log.Tracef("Call:ImportSettings()")
+
+	filePath, err := a.host.OpenFile(&mnd.FileDialog{
+		Directory: a.fixFolderPath(""),
+		Title:     a.log.Translate("Import Settings"),
+		Filters:   mnd.JSONFilter(),
+	})
+	if err != nil {
+		a.host.LogError(err.Error())
+		return nil, errors.New(a.log.Translate("Opening file browser: %v", err))
+	} else if filePath == "" {
+		return nil, nil //nolint:nilnil // user canceled.
+	}
+
+	fileOpen, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.New(a.log.Translate("Opening file: %v", err))
+	}
+	defer fileOpen.Close()
+
+	imported, err := config.ImportJSON(fileOpen)
+	if err != nil {
+		return nil, errors.New(a.log.Translate("Decoding input file failed: %v", err))
+	}
+
+	question := a.log.Translate("Import instances from %s?\nInstances with the same name are replaced.",
+		filepath.Base(filePath))
+	if !a.Ask(a.log.Translate("Import Settings"), question) {
+		return nil, nil //nolint:nilnil // user canceled.
+	}
+
+	settings := a.config.Settings()
+	added, updated := settings.MergeInstances(imported.Instances)
+
+	if settings, err = a.config.Write(settings); err != nil {
+		a.log.Errorf("Error writing config: %v", err.Error())
+		return nil, errors.New(a.log.Translate("Error writing config: %v", err.Error()))
+	}
+
+	a.log.Infof("Imported settings from %s: %d instances added, %d updated", filePath, added, updated)
+
+	return settings, nil
+}
+
+// ConvertConfig changes the config file format. Format may be "json" or "gob".
+func (a *App) ConvertConfig(format string) (*ConfigSaved, error) {
+	a.log.Tracef("Call:ConvertConfig(%s)", format)
+
+	ext := ".conf"
+	if format == "json" {
+		ext = ".json"
+	}
+
+	settings, err := a.config.Convert(ext)
+	if err != nil {
+		a.log.Errorf("Converting config file: %v", err)
+		return nil, errors.New(a.log.Translate("Converting config file: %v", err))
+	}
+
+	msg := a.log.Translate("Config file is now: %s", settings.File)
+	a.log.Infof(msg)
+
+	return &ConfigSaved{Msg: msg, Val: settings.File}, nil
+}
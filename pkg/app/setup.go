@@ -2,6 +2,8 @@ package app
 
 import (
 	"context"
+	"path/filepath"
+	"sync"
 
 	"github.com/Notifiarr/toolbarr/pkg/config"
 	"github.com/Notifiarr/toolbarr/pkg/logs"
@@ -11,12 +13,17 @@ import (
 	wr "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// rootMovesFile is stored next to the config file, so root folder moves may be resumed after a restart.
+const rootMovesFile = "rootmoves.json"
+
 // App struct.
 type App struct {
 	ctx     context.Context
 	log     *logs.Logger
 	config  *config.Config
+	host    Host
 	updates updates
+	history sync.Mutex // guards the import list sync history file.
 	*Config
 }
 
@@ -37,14 +44,11 @@ func New(logger *logs.Logger, config *Config) *App {
 // The context is saved so runtime methods may be called.
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.host = &host{ctx: ctx, log: a.log}
 
 	defer a.log.CapturePanic()
 
-	conf, err := config.Get(&config.Input{
-		File: a.ConfigFile,
-		Name: mnd.Name,
-		Dir:  "com.notifiarr." + mnd.Name,
-	})
+	conf, err := a.openConfig()
 	if err != nil {
 		a.ErrorDialog(a.log.Translate("Config Problem"), err.Error())
 		a.Quit()
@@ -52,12 +56,53 @@ func (a *App) Startup(ctx context.Context) {
 		return
 	}
 
-	a.config = conf
 	a.log.Setup(ctx, conf.Settings().LogConfig)
-	starrs.Startup(ctx, a.Starrs, a.log, a)
+	a.start(conf)
 	a.setupMenu()
 }
 
+// StartHeadless is used instead of Startup when there is no window, like the web server.
+// Logs are written to stderr, and the host handles dialogs and events.
+func (a *App) StartHeadless(ctx context.Context, host Host, level logs.Level) error {
+	a.ctx = ctx
+	a.host = host
+
+	conf, err := a.openConfig()
+	if err != nil {
+		return err
+	}
+
+	a.log.SetupCLI(conf.Settings().LogConfig, level)
+	a.start(conf)
+
+	return nil
+}
+
+func (a *App) openConfig() (*config.Config, error) {
+	return config.Get(&config.Input{ //nolint:wrapcheck
+		File: a.ConfigFile,
+		Name: mnd.Name,
+		Dir:  "com.notifiarr." + mnd.Name,
+	})
+}
+
+// start finishes startup after the config file is opened and logs are set up.
+func (a *App) start(conf *config.Config) {
+	a.config = conf
+
+	for _, notice := range conf.Notices() {
+		a.log.Warnf("Config: %s", notice)
+		a.ErrorDialog(a.log.Translate("Config Problem"), notice)
+	}
+
+	starrs.Startup(a.ctx, a.Starrs, a.log, a.host)
+
+	movesFile := filepath.Join(filepath.Dir(conf.Settings().File), rootMovesFile)
+	if err := starrs.LoadRootMoves(a.Starrs, movesFile); err != nil {
+		a.log.Errorf("Root folder moves: %v", err)
+	}
+}
+
 // setupMenu configures the menu bar at the top of the application.
 func (a *App) setupMenu() {
 	hideMenu := a.AppMenu.AddSubmenu(a.log.Translate("Hide"))
@@ -110,9 +155,5 @@ func (a *App) Quit() {
 
 // ErrorDialog displays an error on screen.
 func (a *App) ErrorDialog(title, msg string) {
-	_, _ = wr.MessageDialog(a.ctx, wr.MessageDialogOptions{
-		Type:    wr.ErrorDialog,
-		Title:   title,
-		Message: msg,
-	})
+	a.host.ErrorDialog(title, msg)
 }
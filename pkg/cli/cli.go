@@ -0,0 +1,265 @@
+// Package cli runs toolbarr without a window. Subcommands reuse the starrs methods
+// the GUI binds, print JSON to stdout, and log to stderr.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/config"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0 // The command succeeded.
+	ExitError = 1 // The command failed.
+	ExitUsage = 2 // The command line was not valid.
+)
+
+var (
+	ErrUsage     = errors.New("invalid usage")
+	ErrNotFound  = errors.New("instance not found")
+	ErrCanceled  = errors.New("canceled; pass -yes to confirm")
+	ErrUnhealthy = errors.New("health check failed")
+	ErrNoFolder  = errors.New("root folder not found")
+)
+
+// command is a subcommand. The args do not include the subcommand name.
+type command struct {
+	usage string
+	run   func(c *cli, args []string) error
+	// ownConfig is true for commands that open the config file themselves.
+	ownConfig bool
+}
+
+// cli holds the running data for a single command.
+type cli struct {
+	ctx    context.Context
+	log    *logs.Logger
+	file   string // config file from -c.
+	assets fs.FS  // embedded frontend.
+	config *config.Config
+	starrs *starrs.Starrs
+	out    io.Writer // stdout: command output.
+	errs   io.Writer // stderr: problems and questions.
+	host   *mnd.Headless
+	yes    bool
+	stop   bool // allows stopping a running starr app before writing to its database.
+	debug  bool
+}
+
+// commands is the list of subcommands. The key is the first argument.
+func commands() map[string]command {
+	return map[string]command{
+		"instances": {usage: "instances list [-app Radarr]", run: (*cli).instances},
+		"test":      {usage: "test <app>/<name>", run: (*cli).test},
+		"health":    {usage: "health [-instance <app>/<name>]", run: (*cli).health},
+		"export": {
+			usage: "export <" + strings.Join(exportKinds(), "|") + "> -instance <name> [-o file.json]",
+			run:   (*cli).export,
+		},
+		"migrate": {
+			usage: "migrate root -instance <name> -from <path> -to <path> [-dry-run] [-yes] [-stop]",
+			run:   (*cli).migrate,
+		},
+		"query": {
+			usage: "query <sql|tables> -instance <name> [-limit 1000] [-timeout 10s] [-csv] [-o file]",
+			run:   (*cli).query,
+		},
+		"serve": {
+			usage:     "serve [-listen ip:port] [-user name] [-password pass] [-cert file -key file]",
+			run:       (*cli).serve,
+			ownConfig: true,
+		},
+	}
+}
+
+// Run executes a subcommand and returns the process exit code.
+// The wails runtime is never used, so this works on a server without a display.
+// Assets are the embedded frontend files, for the web server.
+func Run(log *logs.Logger, configFile string, assets fs.FS, args []string) int {
+	return run(log, configFile, assets, args, os.Stdout, os.Stderr)
+}
+
+// run executes a subcommand with the provided stdout and stderr.
+func run(log *logs.Logger, configFile string, assets fs.FS, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		Usage(stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands()[args[0]]
+	if !ok {
+		Usage(stderr)
+		return ExitUsage
+	}
+
+	cli := &cli{
+		ctx:    context.Background(),
+		log:    log,
+		file:   configFile,
+		assets: assets,
+		starrs: &starrs.Starrs{},
+		out:    stdout,
+		errs:   stderr,
+	}
+
+	if !cmd.ownConfig {
+		conf, err := config.Get(&config.Input{
+			File: configFile,
+			Name: mnd.Name,
+			Dir:  "com.notifiarr." + mnd.Name,
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "Config Problem:", err)
+			return ExitError
+		}
+		defer conf.Stop()
+
+		for _, notice := range conf.Notices() {
+			fmt.Fprintln(stderr, "Config Problem:", notice)
+		}
+
+		cli.config = conf
+	}
+
+	err := cmd.run(cli, args[1:])
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprintf(stderr, "Error: %v\nUsage: %s %s\n", err, mnd.Name, cmd.usage)
+		return ExitUsage
+	default:
+		fmt.Fprintln(stderr, "Error:", err)
+		return ExitError
+	}
+}
+
+// Usage prints the list of commands.
+func Usage(output io.Writer) {
+	list := commands()
+	names := make([]string, 0, len(list))
+
+	for name := range list {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	fmt.Fprintf(output, "Usage: %s [-c config] <command> [options]\nCommands:\n", mnd.Name)
+
+	for _, name := range names {
+		fmt.Fprintf(output, "  %s %s\n", mnd.Name, list[name].usage)
+	}
+}
+
+// flags returns a flag set with the options every command accepts.
+func (c *cli) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&c.yes, "yes", false, "Answer yes to every question.")
+	flags.BoolVar(&c.debug, "debug", false, "Print debug logs to stderr.")
+
+	return flags
+}
+
+// parse reads flags and returns the positional arguments. Flags may come before or after them.
+func (c *cli) parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+		}
+
+		if flags.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	level := logs.LogLevelNormal
+	if c.debug {
+		level = logs.LogLevelDebug
+	}
+
+	if c.config == nil {
+		return positional, nil // the command opens the config itself.
+	}
+
+	// Questions are answered with -yes, and file pickers are not available.
+	// Running apps are only stopped with -stop; nothing starts them again.
+	c.host = &mnd.Headless{
+		Answer: c.yes,
+		Stop:   c.stop,
+		Output: c.errs,
+		OnEmit: func(event string, data any) { c.log.Debugf("Event %s: %v", event, data) },
+	}
+
+	c.log.SetupCLI(c.config.Settings().LogConfig, level)
+	starrs.Startup(c.ctx, c.starrs, c.log, c.host)
+
+	return positional, nil
+}
+
+// print writes a value to stdout as json.
+func (c *cli) print(value any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("encoding output: %w", err)
+	}
+
+	return nil
+}
+
+// instance finds a configured instance. The name may be prefixed with the app: radarr/4k.
+func (c *cli) instance(name string) (*starrs.AppConfig, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: an instance name is required", ErrUsage)
+	}
+
+	app, name, hasApp := strings.Cut(name, "/")
+	if !hasApp {
+		app, name = "", app
+	}
+
+	var found []starrs.AppConfig
+
+	for appName, list := range c.config.Settings().Instances {
+		if app != "" && !strings.EqualFold(app, appName) {
+			continue
+		}
+
+		for _, instance := range list {
+			if strings.EqualFold(instance.Name, name) {
+				instance.App = appName
+				found = append(found, instance)
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d instances are named %s; use <app>/<name>", ErrUsage, len(found), name)
+	}
+}
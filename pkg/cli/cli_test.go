@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/config"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"github.com/zalando/go-keyring"
+	"golift.io/starr"
+)
+
+// TestMain keeps encryption keys in memory, so tests never write to the OS keyring.
+func TestMain(m *testing.M) {
+	keyring.MockInit()
+	os.Exit(m.Run())
+}
+
+// Secrets in the test config file. None of these may be printed.
+const (
+	testKey  = "secretapikey"
+	testPass = "secretpassword"
+)
+
+// writeConfig writes a config file with the provided instances, and returns its path.
+func writeConfig(t *testing.T, instances starrs.Instances) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), mnd.Name+".json")
+
+	conf, err := config.Get(&config.Input{File: file, Name: mnd.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.Stop()
+
+	settings := conf.Settings()
+	settings.Instances = instances
+
+	if _, err := conf.Write(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+// runCLI runs a command against a config file, and returns the exit code, stdout and stderr.
+func runCLI(t *testing.T, file string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(logs.New(), file, nil, args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	file := writeConfig(t, starrs.Instances{
+		starr.Radarr.String(): {{Name: "same", URL: "http://127.0.0.1:1/"}},
+		starr.Sonarr.String(): {{Name: "same", URL: "http://127.0.0.1:1/"}},
+	})
+
+	tests := []struct {
+		args   []string
+		code   int
+		stderr string
+	}{
+		{args: []string{}, code: ExitUsage, stderr: "Commands:"},
+		{args: []string{"nope"}, code: ExitUsage, stderr: "Commands:"},
+		{args: []string{"instances"}, code: ExitUsage, stderr: "unknown instances command"},
+		{args: []string{"instances", "list", "extra"}, code: ExitUsage, stderr: "unknown instances command"},
+		{args: []string{"instances", "list", "-bogus"}, code: ExitUsage, stderr: "flag provided but not defined"},
+		{args: []string{"instances", "-app", "Sonarr", "list"}, code: ExitOK},
+		{args: []string{"instances", "list", "-h"}, code: ExitUsage, stderr: "Usage: " + mnd.Name + " instances"},
+		{args: []string{"test"}, code: ExitUsage, stderr: "provide one instance"},
+		{args: []string{"test", "missing"}, code: ExitError, stderr: "instance not found"},
+		{args: []string{"test", "same"}, code: ExitUsage, stderr: "use <app>/<name>"},
+		{args: []string{"health", "-instance", "missing"}, code: ExitError, stderr: "instance not found"},
+		{args: []string{"export", "nothing", "-instance", "radarr/same"}, code: ExitUsage, stderr: "cannot export"},
+		{args: []string{"export", "-instance", "radarr/same"}, code: ExitUsage, stderr: "provide one thing"},
+		{args: []string{"migrate", "other"}, code: ExitUsage, stderr: "unknown migrate command"},
+		{args: []string{"migrate", "root", "-instance", "same"}, code: ExitUsage, stderr: "-from and -to are required"},
+		{args: []string{"query", "-instance", "same"}, code: ExitUsage, stderr: "provide one query"},
+		{args: []string{"query", "tables"}, code: ExitUsage, stderr: "instance name is required"},
+	}
+
+	for _, test := range tests {
+		code, _, stderr := runCLI(t, file, test.args...)
+		if code != test.code || !strings.Contains(stderr, test.stderr) {
+			t.Errorf("%v: wrong exit code %d or output, expected %d and %q: %s",
+				test.args, code, test.code, test.stderr, stderr)
+		}
+	}
+}
+
+func TestInstancesList(t *testing.T) {
+	t.Parallel()
+
+	file := writeConfig(t, starrs.Instances{
+		starr.Sonarr.String(): {
+			{Name: "tv", URL: "http://sonarr:8989/", Key: testKey, Timeout: time.Minute},
+			{Name: "anime", URL: "http://anime:8989/", User: "me", Pass: testPass, Form: true},
+		},
+		starr.Radarr.String(): {{Name: "movies", URL: "http://radarr:7878/", Key: testKey, Pass: testPass}},
+	})
+
+	tests := []struct {
+		args  []string
+		names []string // sorted by app, then name.
+	}{
+		{args: []string{"instances", "list"}, names: []string{"movies", "anime", "tv"}},
+		{args: []string{"instances", "list", "-app", "sonarr"}, names: []string{"anime", "tv"}},
+		{args: []string{"instances", "list", "-app", "Lidarr"}, names: []string{}},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runCLI(t, file, test.args...)
+		if code != ExitOK {
+			t.Fatalf("%v: exit code %d: %s", test.args, code, stderr)
+		}
+
+		if strings.Contains(stdout, testKey) || strings.Contains(stdout, testPass) ||
+			strings.Contains(stderr, testKey) || strings.Contains(stderr, testPass) {
+			t.Errorf("%v: secrets were printed: %s", test.args, stdout)
+		}
+
+		var list []*listedInstance
+		if err := json.Unmarshal([]byte(stdout), &list); err != nil {
+			t.Fatalf("%v: output is not json: %v", test.args, err)
+		}
+
+		names := []string{}
+		for _, instance := range list {
+			names = append(names, instance.Name)
+
+			if instance.HasKey != (instance.Name != "anime") {
+				t.Errorf("%v: %s has the wrong key flag", test.args, instance.Name)
+			}
+		}
+
+		if strings.Join(names, ",") != strings.Join(test.names, ",") {
+			t.Errorf("%v: wrong instances: %v, expected %v", test.args, names, test.names)
+		}
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "radarr.db")
+	if err := starrtest.NewDB(dbPath, starr.Radarr,
+		"INSERT INTO RootFolders (Path) VALUES ('/movies/')",
+		"INSERT INTO Movies (Id, Path, Monitored, QualityProfileId, MovieFileId, MinimumAvailability, MovieMetadataId) "+
+			"VALUES (1, '/movies/A', 1, 1, 0, 0, 1)",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	file := writeConfig(t, starrs.Instances{starr.Radarr.String(): {{Name: "movies", DBPath: dbPath}}})
+	args := []string{"migrate", "root", "-instance", "movies", "-from", "/movies/", "-dry-run"}
+
+	tests := []struct {
+		to   string
+		want string // -to with a trailing slash added.
+	}{
+		{to: "/films", want: "/films/"},
+		{to: "/films/", want: "/films/"},
+		{to: `C:\films`, want: `C:\films\`},
+		{to: `\\nas\films\`, want: `\\nas\films\`},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runCLI(t, file, append(args, "-to", test.to)...)
+		if code != ExitOK {
+			t.Fatalf("%s: exit code %d: %s", test.to, code, stderr)
+		}
+
+		var plan struct {
+			To    string
+			Merge bool
+			Items map[string]int
+		}
+
+		if err := json.Unmarshal([]byte(stdout), &plan); err != nil {
+			t.Fatalf("%s: output is not json: %v", test.to, err)
+		}
+
+		if plan.To != test.want || plan.Merge || plan.Items["Movies"] != 1 {
+			t.Errorf("%s: wrong plan: %+v", test.to, plan)
+		}
+	}
+
+	// A dry run, or a migration that is not confirmed, changes nothing.
+	if code, _, stderr := runCLI(t, file, "migrate", "root", "-instance", "movies", "-from", "/movies/",
+		"-to", "/films"); code != ExitError || !strings.Contains(stderr, "canceled") {
+		t.Errorf("a migration without -yes should be canceled: %d: %s", code, stderr)
+	}
+
+	if code, _, stderr := runCLI(t, file, "migrate", "root", "-instance", "movies", "-from", "/missing/",
+		"-to", "/films", "-dry-run"); code != ExitError || !strings.Contains(stderr, "root folder not found") {
+		t.Errorf("a missing root folder should fail: %d: %s", code, stderr)
+	}
+
+	code, stdout, _ := runCLI(t, file, append(args, "-to", "/films")...)
+	if code != ExitOK || !strings.Contains(stdout, `"Movies": 1`) {
+		t.Errorf("the movie should still be in the old root folder: %s", stdout)
+	}
+}
+
+func TestHealthExitCode(t *testing.T) {
+	t.Parallel()
+
+	server := starrtest.New(starr.Sonarr)
+	defer server.Close()
+
+	file := writeConfig(t, starrs.Instances{
+		starr.Sonarr.String(): {{Name: "up", URL: server.URL(), Key: starrtest.APIKey, Timeout: 5 * time.Second}},
+		starr.Radarr.String(): {{Name: "down", URL: "http://127.0.0.1:1/", Key: starrtest.APIKey, Timeout: time.Second}},
+	})
+	healthy := writeConfig(t, starrs.Instances{
+		starr.Sonarr.String(): {{Name: "up", URL: server.URL(), Key: starrtest.APIKey, Timeout: 5 * time.Second}},
+	})
+
+	tests := []struct {
+		file     string
+		args     []string
+		code     int
+		severity string
+	}{
+		{file: healthy, args: []string{"health"}, code: ExitOK, severity: starrs.SeverityOK},
+		{file: file, args: []string{"health"}, code: ExitError, severity: starrs.SeverityError},
+		{file: file, args: []string{"health", "-instance", "sonarr/up"}, code: ExitOK, severity: starrs.SeverityOK},
+		{file: file, args: []string{"health", "-instance", "down"}, code: ExitError, severity: starrs.SeverityError},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runCLI(t, test.file, test.args...)
+		if code != test.code {
+			t.Errorf("%v: wrong exit code %d, expected %d: %s", test.args, code, test.code, stderr)
+		}
+
+		// The report is printed either way, so scripts can see what failed.
+		var report struct{ Severity string }
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil || report.Severity != test.severity {
+			t.Errorf("%v: wrong report: %v: %s", test.args, err, stdout)
+		}
+
+		if test.code == ExitError && !strings.Contains(stderr, ErrUnhealthy.Error()) {
+			t.Errorf("%v: the error should be printed: %s", test.args, stderr)
+		}
+	}
+}
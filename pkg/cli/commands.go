@@ -0,0 +1,366 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+// listedInstance is an instance without its secrets, for printing.
+type listedInstance struct {
+	App     string `json:"app"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	User    string `json:"user,omitempty"`
+	DBPath  string `json:"dbPath,omitempty"`
+	HasKey  bool   `json:"hasKey"`
+	SSL     bool   `json:"ssl"`
+	Form    bool   `json:"form"`
+	Timeout string `json:"timeout"`
+}
+
+// instances lists the configured instances. Passwords and API keys are not printed.
+func (c *cli) instances(args []string) error {
+	var app string
+
+	flags := c.flags("instances")
+	flags.StringVar(&app, "app", "", "Only list instances for this app.")
+
+	positional, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	} else if len(positional) != 1 || positional[0] != "list" {
+		return fmt.Errorf("%w: unknown instances command", ErrUsage)
+	}
+
+	list := []*listedInstance{}
+
+	for appName, instances := range c.config.Settings().Instances {
+		if app != "" && !strings.EqualFold(app, appName) {
+			continue
+		}
+
+		for _, instance := range instances {
+			list = append(list, &listedInstance{
+				App:     appName,
+				Name:    instance.Name,
+				URL:     instance.URL,
+				User:    instance.User,
+				DBPath:  instance.DBPath,
+				HasKey:  instance.Key != "",
+				SSL:     instance.SSL,
+				Form:    instance.Form,
+				Timeout: instance.Timeout.String(),
+			})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].App != list[j].App {
+			return list[i].App < list[j].App
+		}
+
+		return list[i].Name < list[j].Name
+	})
+
+	return c.print(list)
+}
+
+// test checks the connection to an instance, and its database if one is configured.
+func (c *cli) test(args []string) error {
+	positional, err := c.parse(c.flags("test"), args)
+	if err != nil {
+		return err
+	} else if len(positional) != 1 {
+		return fmt.Errorf("%w: provide one instance", ErrUsage)
+	}
+
+	config, err := c.instance(positional[0])
+	if err != nil {
+		return err
+	}
+
+	msg, err := c.starrs.TestInstance(config)
+	if err != nil {
+		return err
+	}
+
+	// The message is made for the GUI; it is a list of html items.
+	results := []string{}
+
+	for _, line := range strings.Split(strings.ReplaceAll(msg, "<li>", ""), "</li>") {
+		if line = strings.TrimSpace(line); line != "" {
+			results = append(results, line)
+		}
+	}
+
+	return c.print(map[string]any{
+		"app":     config.App,
+		"name":    config.Name,
+		"success": true,
+		"results": results,
+	})
+}
+
+// health prints the health of one instance, or every instance.
+func (c *cli) health(args []string) error {
+	var name string
+
+	flags := c.flags("health")
+	flags.StringVar(&name, "instance", "", "Only check this instance.")
+
+	if _, err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if name == "" {
+		matrix := c.starrs.HealthAll(c.config.Settings().Instances)
+		if err := c.print(matrix); err != nil {
+			return err
+		}
+
+		if matrix.Severity == starrs.SeverityError {
+			return fmt.Errorf("%w: one or more instances are unhealthy", ErrUnhealthy)
+		}
+
+		return nil
+	}
+
+	config, err := c.instance(name)
+	if err != nil {
+		return err
+	}
+
+	health := c.starrs.Health(config)
+	if err := c.print(health); err != nil {
+		return err
+	}
+
+	if health.Severity == starrs.SeverityError {
+		return fmt.Errorf("%w: %s", ErrUnhealthy, config.Name)
+	}
+
+	return nil
+}
+
+// exporters returns the data for each kind of export.
+func exporters() map[string]func(*starrs.Starrs, *starrs.AppConfig) (any, error) {
+	return map[string]func(*starrs.Starrs, *starrs.AppConfig) (any, error){
+		"indexers":         (*starrs.Starrs).Indexers,
+		"downloadclients":  (*starrs.Starrs).Downloaders,
+		"importlists":      (*starrs.Starrs).ImportLists,
+		"exclusions":       (*starrs.Starrs).Exclusions,
+		"qualityprofiles":  (*starrs.Starrs).QualityProfiles,
+		"metadataprofiles": (*starrs.Starrs).MetadataProfiles,
+		"customfilters":    (*starrs.Starrs).CustomFilters,
+		"rootfolders":      (*starrs.Starrs).RootFolders,
+		"tags": func(s *starrs.Starrs, config *starrs.AppConfig) (any, error) {
+			return s.Tags(config)
+		},
+	}
+}
+
+func exportKinds() []string {
+	kinds := []string{}
+	for kind := range exporters() {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// export writes instance data to a json file, or stdout.
+// The output matches what the GUI exports, so it may be imported there.
+func (c *cli) export(args []string) error {
+	var name, output string
+
+	flags := c.flags("export")
+	flags.StringVar(&name, "instance", "", "Instance to export from.")
+	flags.StringVar(&output, "o", "", "Write to this file instead of stdout.")
+
+	positional, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	} else if len(positional) != 1 {
+		return fmt.Errorf("%w: provide one thing to export", ErrUsage)
+	}
+
+	exporter, ok := exporters()[strings.ToLower(positional[0])]
+	if !ok {
+		return fmt.Errorf("%w: cannot export %s", ErrUsage, positional[0])
+	}
+
+	config, err := c.instance(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := exporter(c.starrs, config)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return c.print(data)
+	}
+
+	// Exports may contain passwords for download clients and indexers.
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mnd.Mode0600)
+	if err != nil {
+		return fmt.Errorf("opening output file: %w", err)
+	}
+	defer file.Close()
+
+	c.out = file
+	if err = c.print(data); err != nil {
+		return err
+	}
+
+	c.log.Infof("Exported %s from %s to %s", positional[0], config.Name, output)
+
+	return nil
+}
+
+// migrate changes a root folder path in an instance's database.
+func (c *cli) migrate(args []string) error {
+	var name, from, dest string
+
+	var dryRun bool
+
+	flags := c.flags("migrate")
+	flags.StringVar(&name, "instance", "", "Instance with the database to update.")
+	flags.StringVar(&from, "from", "", "Root folder path to change.")
+	flags.StringVar(&dest, "to", "", "New root folder path.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Print what would change; do not update the database.")
+	flags.BoolVar(&c.stop, "stop", false, "Stop the app with its API if it's running. It does not start again.")
+
+	positional, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	} else if len(positional) != 1 || positional[0] != "root" {
+		return fmt.Errorf("%w: unknown migrate command", ErrUsage)
+	} else if from == "" || dest == "" {
+		return fmt.Errorf("%w: -from and -to are required", ErrUsage)
+	}
+
+	// Item paths are joined to the new root folder, so it needs a slash. The GUI adds one too.
+	if !strings.HasSuffix(dest, "/") && !strings.HasSuffix(dest, `\`) {
+		if strings.Contains(dest, `\`) {
+			dest += `\`
+		} else {
+			dest += "/"
+		}
+	}
+
+	config, err := c.instance(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := c.starrs.MigratorInfo(config)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(info.RootFolders, from) {
+		return fmt.Errorf("%w: %s; found: %s", ErrNoFolder, from, strings.Join(info.RootFolders, ", "))
+	}
+
+	if dryRun {
+		items := make(map[string]int)
+		for table, folders := range info.Folders {
+			items[table] = folders[from]
+		}
+
+		return c.print(map[string]any{
+			"instance": config.Name,
+			"from":     from,
+			"to":       dest,
+			"merge":    slices.Contains(info.RootFolders, dest),
+			"items":    items,
+		})
+	}
+
+	question := fmt.Sprintf("Change root folder in %s database?\n%s => %s", config.Name, from, dest)
+	if !c.host.Ask("Migrate Root Folder", question) {
+		return ErrCanceled
+	}
+
+	reply, err := c.starrs.UpdateRootFolder(config, from, dest)
+	if err != nil {
+		return err
+	}
+
+	return c.print(reply)
+}
+
+// query runs a read-only query against an instance's database, or lists its tables.
+func (c *cli) query(args []string) error {
+	var (
+		name, output string
+		asCSV        bool
+		query        starrs.Query
+	)
+
+	flags := c.flags("query")
+	flags.StringVar(&name, "instance", "", "Instance with the database to query.")
+	flags.IntVar(&query.Limit, "limit", 0, "Maximum rows to return. Default 1000.")
+	flags.DurationVar(&query.Timeout, "timeout", 0, "How long the query may run. Default 10s.")
+	flags.BoolVar(&asCSV, "csv", false, "Print csv instead of json.")
+	flags.StringVar(&output, "o", "", "Write to this file instead of stdout.")
+
+	positional, err := c.parse(flags, args)
+	if err != nil {
+		return err
+	} else if len(positional) != 1 {
+		return fmt.Errorf("%w: provide one query, or tables", ErrUsage)
+	}
+
+	config, err := c.instance(name)
+	if err != nil {
+		return err
+	}
+
+	if output != "" {
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mnd.Mode0640)
+		if err != nil {
+			return fmt.Errorf("opening output file: %w", err)
+		}
+		defer file.Close()
+
+		c.out = file
+	}
+
+	if strings.EqualFold(positional[0], "tables") {
+		tables, err := c.starrs.DBTables(config)
+		if err != nil {
+			return err
+		}
+
+		return c.print(tables)
+	}
+
+	query.SQL = positional[0]
+
+	result, err := c.starrs.RunQuery(config, &query)
+	if err != nil {
+		return err
+	}
+
+	if result.Truncated {
+		c.log.Warnf("Stopped at %d rows; use -limit to get more.", len(result.Rows))
+	}
+
+	if asCSV {
+		return result.WriteCSV(c.out)
+	}
+
+	return result.WriteJSON(c.out)
+}
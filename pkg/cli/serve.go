@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/server"
+)
+
+// passwordEnv may be used instead of -password, so the password is not in the process list.
+const passwordEnv = "TOOLBARR_PASSWORD"
+
+// serve runs the web server until it's interrupted.
+func (c *cli) serve(args []string) error {
+	config := &server.Config{Assets: c.assets}
+
+	flags := c.flags("serve")
+	flags.StringVar(&config.Listen, "listen", "127.0.0.1:5454", "IP and port to listen on.")
+	flags.StringVar(&config.Username, "user", "admin", "Username to log in with.")
+	flags.StringVar(&config.Password, "password", os.Getenv(passwordEnv), "Password to log in with. Or set "+passwordEnv)
+	flags.StringVar(&config.CertFile, "cert", "", "TLS certificate file. Enables https.")
+	flags.StringVar(&config.KeyFile, "key", "", "TLS key file.")
+
+	if _, err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if c.debug {
+		config.Level = logs.LogLevelDebug
+	}
+
+	ctx, cancel := signal.NotifyContext(c.ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	application := app.New(c.log, &app.Config{
+		Logger:     c.log,
+		ConfigFile: c.file,
+		Starrs:     c.starrs,
+	})
+
+	return server.New(c.log, config, application).Run(ctx) //nolint:wrapcheck
+}
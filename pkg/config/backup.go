@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+)
+
+/* Config files are written atomically, and a few previous versions are kept as backups. */
+
+const (
+	// configBackups is the number of previous config file versions to keep.
+	configBackups = 5
+	// brokenExt is appended to a config file that could not be read.
+	brokenExt = ".broken"
+)
+
+// writeFile encodes settings, rotates the backups, and writes the config file with mnd.WriteFile.
+// A crash or full disk while writing leaves the existing config file untouched.
+func writeFile(file string, settings *Settings) error {
+	var buf bytes.Buffer
+	if err := encodeSettings(file, &buf, settings); err != nil {
+		return fmt.Errorf("encoding config file: %s: %w", file, err)
+	}
+
+	if err := rotateBackups(file); err != nil {
+		return err
+	}
+
+	if err := mnd.WriteFile(file, buf.Bytes()); err != nil {
+		return fmt.Errorf("writing config file: %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// writeFile writes the config file. A damaged config file, left in place when settings were restored
+// from a backup, is renamed first, so it does not replace a good backup. It is put back if the write fails.
+func (c *Config) writeFile(file string, settings *Settings) error {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	if c.broken == "" {
+		return writeFile(file, settings)
+	}
+
+	if err := os.Rename(c.broken, c.broken+brokenExt); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("renaming damaged config file: %w", err)
+	}
+
+	if err := writeFile(file, settings); err != nil {
+		_ = os.Rename(c.broken+brokenExt, c.broken) // the backups are tried again on the next start.
+		return err
+	}
+
+	c.broken = ""
+
+	return nil
+}
+
+// backupFile returns the path for a config file backup. The extension is kept so the format is known.
+// ie. toolbarr.conf => toolbarr.backup1.conf.
+func backupFile(file string, idx int) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + ".backup" + strconv.Itoa(idx) + ext
+}
+
+// rotateBackups moves each backup down one slot, and copies the current config file into the first slot.
+func rotateBackups(file string) error {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil // nothing to back up.
+	} else if err != nil {
+		return fmt.Errorf("reading config file for backup: %s: %w", file, err)
+	}
+
+	for idx := configBackups - 1; idx > 0; idx-- {
+		if fileExists(backupFile(file, idx)) {
+			_ = os.Rename(backupFile(file, idx), backupFile(file, idx+1))
+		}
+	}
+
+	if err := os.WriteFile(backupFile(file, 1), data, 0o600); err != nil { //nolint:gomnd
+		return fmt.Errorf("writing config file backup: %w", err)
+	}
+
+	return nil
+}
+
+// readBackup returns the settings from the newest readable backup, and the backup file path.
+// Returns nil settings if no backup is readable.
+func readBackup(file string) (*Settings, string) {
+	for idx := 1; idx <= configBackups; idx++ {
+		if settings, err := readSettings(backupFile(file, idx)); err == nil {
+			return settings, backupFile(file, idx)
+		}
+	}
+
+	return nil, ""
+}
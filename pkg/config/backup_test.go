@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileBackups(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"toolbarr.conf", "toolbarr.json"} {
+		file := filepath.Join(t.TempDir(), name)
+
+		for idx := 1; idx <= configBackups+2; idx++ {
+			if err := writeFile(file, &Settings{Updates: "v" + strconv.Itoa(idx)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Newest first: the config file, then each backup.
+		tests := []struct {
+			file    string
+			updates string
+		}{
+			{file: file, updates: "v7"},
+			{file: backupFile(file, 1), updates: "v6"},
+			{file: backupFile(file, 2), updates: "v5"},
+			{file: backupFile(file, configBackups), updates: "v2"},
+		}
+
+		for _, test := range tests {
+			settings, err := readSettings(test.file)
+			if err != nil || settings.Updates != test.updates {
+				t.Errorf("%s: wrong version: %+v, %v", filepath.Base(test.file), settings, err)
+			}
+		}
+
+		// Only the config file and its backups are left; the temp files were renamed.
+		files, _ := os.ReadDir(filepath.Dir(file))
+		if len(files) != configBackups+1 || fileExists(backupFile(file, configBackups+1)) {
+			t.Errorf("%s: wrong files: %v", name, files)
+		}
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "/a/toolbarr.conf", want: "/a/toolbarr.backup1.conf"},
+		{file: "/a/toolbarr.json", want: "/a/toolbarr.backup1.json"},
+		{file: "/a.b/toolbarr", want: "/a.b/toolbarr.backup1"},
+	}
+
+	for _, test := range tests {
+		if got := backupFile(test.file, 1); got != test.want {
+			t.Errorf("%s: wrong backup file: %s", test.file, got)
+		}
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		broken   int    // how many of the newest files are damaged, starting with the config file.
+		restored string // the version that opens, empty if none.
+	}{
+		{desc: "good config file", broken: 0, restored: "v3"},
+		{desc: "damaged config file", broken: 1, restored: "v2"},
+		{desc: "damaged first backup", broken: 2, restored: "v1"},
+		{desc: "nothing readable", broken: 3},
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "toolbarr.json")
+
+		for idx := 1; idx <= 3; idx++ {
+			if err := writeFile(file, &Settings{Updates: "v" + strconv.Itoa(idx), SchemaVersion: SchemaVersion}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for idx := range test.broken {
+			damaged := file
+			if idx > 0 {
+				damaged = backupFile(file, idx)
+			}
+
+			if err := os.WriteFile(damaged, []byte("{not json"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		config, err := Get(&Input{File: file, Name: "toolbarr"})
+		if test.restored == "" {
+			if err == nil || fileExists(file+brokenExt) || !fileExists(file) {
+				t.Errorf("%s: expected an error, and the damaged file left in place: %v", test.desc, err)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+
+		if updates := config.Settings().Updates; updates != test.restored {
+			t.Errorf("%s: wrong version: %s, expected %s", test.desc, updates, test.restored)
+		}
+
+		// A restored config is written again, the damaged file is kept, and the user is told.
+		if restored := test.broken > 0; restored != fileExists(file+brokenExt) ||
+			restored != (len(config.Notices()) == 1) || (restored && !strings.Contains(config.Notices()[0], "backup")) {
+			t.Errorf("%s: wrong notices: %v", test.desc, config.Notices())
+		}
+
+		if settings, err := readSettings(file); err != nil || settings.Updates != test.restored {
+			t.Errorf("%s: the restored settings were not written: %v", test.desc, err)
+		}
+
+		config.Stop()
+	}
+}
+
+// TestRestoreBackupNotDecrypted damages the config file, and makes the backup's secrets unreadable.
+// Nothing is written, so the damaged file stays in place until the settings are saved.
+func TestRestoreBackupNotDecrypted(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "toolbarr.json")
+	instances := testInstances()
+
+	// Encrypted with a key this config file will never get.
+	if err := encryptSecrets(bytes.Repeat([]byte{1}, keySize), instances); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backup is an old schema version, so it's migrated from the backup file.
+	for _, version := range []int{0, SchemaVersion} {
+		if err := writeFile(file, &Settings{Instances: instances, SchemaVersion: version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	damaged := []byte("{not json")
+	if err := os.WriteFile(file, damaged, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Get(&Input{File: file, Name: "toolbarr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer config.Stop()
+
+	if len(config.Notices()) != 2 || !strings.Contains(config.Notices()[1], "decrypted") {
+		t.Errorf("the user should be told about the backup and the secrets: %v", config.Notices())
+	}
+
+	if data, _ := os.ReadFile(file); !bytes.Equal(data, damaged) || fileExists(file+brokenExt) {
+		t.Errorf("the damaged file should not move until the settings are written: %s", data)
+	}
+
+	schema := strings.TrimSuffix(file, jsonExt) + ".schema0" + jsonExt
+	if settings, err := readSettings(schema); err != nil || settings.SchemaVersion != 0 {
+		t.Errorf("the backup should be saved before it's migrated: %v", err)
+	}
+
+	if _, err := config.Write(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(file + brokenExt); !bytes.Equal(data, damaged) {
+		t.Errorf("the damaged file should be saved when the settings are written: %s", data)
+	}
+
+	// The good backup is still the newest backup; the damaged file never went into the rotation.
+	if settings, err := readSettings(backupFile(file, 1)); err != nil || settings.SchemaVersion != 0 {
+		t.Errorf("the damaged file replaced a backup: %v", err)
+	}
+
+	settings, err := readSettings(file)
+	if err != nil || settings.Instances["Radarr"][1].Pass != instances["Radarr"][1].Pass {
+		t.Errorf("secrets that could not be decrypted should be written unchanged: %v", err)
+	}
+}
@@ -2,10 +2,11 @@
 package config
 
 import (
-	"encoding/gob"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Notifiarr/toolbarr/pkg/logs"
@@ -17,9 +18,19 @@ import (
 	"golift.io/starr"
 )
 
-const confExt = ".conf"
+// Config file extensions. The extension decides the format: gob or json.
+const (
+	confExt = ".conf"
+	jsonExt = ".json"
+)
+
+// defaultTimeout is used for instances without a timeout.
+const defaultTimeout = 2 * time.Minute
 
-var ErrEmptyInput = fmt.Errorf("input must have at least name or path")
+var (
+	ErrEmptyInput    = fmt.Errorf("input must have at least name or path")
+	ErrInvalidFormat = fmt.Errorf("invalid config file format")
+)
 
 // Input data to open a config file.
 // If Dir!="" then config is placed in a sub directory.
@@ -40,6 +51,11 @@ type Config struct {
 	settings *Settings
 	ask      chan *Settings
 	rep      chan *Settings
+	fileMu   sync.RWMutex
+	key      []byte // encrypts secrets in the config file.
+	keyMu    sync.Mutex
+	notices  []string // problems found while opening the config file.
+	broken   string   // damaged config file, moved out of the backup rotation on the next write. Uses fileMu.
 }
 
 // Get opens/reads or creates/writes a config file.
@@ -66,9 +82,12 @@ func (i *Input) load() (*Config, error) {
 		return nil, fmt.Errorf("creating config dir:  %s: %w", configDir, err)
 	}
 
-	i.File = filepath.Join(configDir, i.Name+confExt)
-	if _, err = os.Stat(i.File); err == nil {
-		return i.openConfigFile()
+	// A json config file wins if both exist.
+	for _, ext := range []string{jsonExt, confExt} {
+		i.File = filepath.Join(configDir, i.Name+ext)
+		if _, err = os.Stat(i.File); err == nil {
+			return i.openConfigFile()
+		}
 	}
 
 	return i.defaultConfig()
@@ -120,8 +139,10 @@ func (i *Input) newConfig(settings *Settings) *Config {
 				starr.Sonarr.String():   []starrs.AppConfig{},
 				starr.Whisparr.String(): []starrs.AppConfig{},
 			},
-			Hide:    make(map[string]bool),
-			Updates: "production",
+			Hide:          make(map[string]bool),
+			Reports:       []starrs.Report{},
+			Updates:       "production",
+			SchemaVersion: SchemaVersion,
 		}
 	}
 
@@ -162,6 +183,13 @@ func (i *Input) openCustomPath() (*Config, error) {
 		return i.openConfigFile()
 	}
 
+	// Converting formats: a custom json path was provided, and a gob file with the same name exists.
+	if gobFile := strings.TrimSuffix(i.File, jsonExt) + confExt; isJSON(i.File) {
+		if _, err := os.Stat(gobFile); err == nil {
+			return i.convertConfigFile(gobFile)
+		}
+	}
+
 	// Custom config path does not exist, so create it.
 	config := i.newConfig(nil)
 
@@ -176,30 +204,92 @@ func (i *Input) openCustomPath() (*Config, error) {
 }
 
 func (i *Input) openConfigFile() (*Config, error) {
-	cnfOpen, err := os.Open(i.File)
+	var notices []string
+
+	source := i.File // the file the settings came from.
+
+	settings, err := readSettings(i.File)
+	if err != nil {
+		// The config file is damaged. Try the backups before giving up.
+		// The damaged file stays in place until the restored settings are written.
+		restored, backup := readBackup(i.File)
+		if restored == nil {
+			return nil, err
+		}
+
+		source = backup
+		settings = restored
+		notices = append(notices, fmt.Sprintf("The config file could not be read: %v\n"+
+			"Settings were restored from the newest readable backup: %s\n"+
+			"The damaged file is saved as %s when the settings are written", err, backup, i.File+brokenExt))
+	}
+
+	key, err := getKey(i.File, hasEncrypted(settings.Instances))
 	if err != nil {
 		return nil, fmt.Errorf("opening config file: %s: %w", i.File, err)
 	}
+
+	migrated, err := migrateSettings(i.File, source, i.setDefaults(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := decryptSecrets(key, settings.Instances)
+	config := i.newConfig(settings)
+	config.key = key
+	config.notices = notices
+
+	if source != i.File {
+		config.broken = i.File
+	}
+
+	if err != nil {
+		// Do not write the config file; the secrets stay encrypted in it, and may be decrypted with the right key.
+		config.notices = append(config.notices, fmt.Sprintf("Some instance passwords or API keys could not be "+
+			"decrypted. Restore the encryption key, or enter them again: %v", err))
+
+		return config, nil
+	}
+
+	// Restored from backup, migrated, or older config file with plain text secrets. Write it again.
+	if len(notices) > 0 || migrated || plain > 0 {
+		if _, err := config.Write(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// readSettings opens and decodes a config file.
+func readSettings(file string) (*Settings, error) {
+	cnfOpen, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("opening config file: %s: %w", file, err)
+	}
 	defer cnfOpen.Close()
 
 	var settings Settings
-	if err = gob.NewDecoder(cnfOpen).Decode(&settings); err != nil {
-		return nil, fmt.Errorf("decoding config file:  %s: %w", i.File, err)
+	if err = decodeSettings(file, cnfOpen, &settings); err != nil {
+		return nil, fmt.Errorf("decoding config file:  %s: %w", file, err)
 	}
 
-	return i.newConfig(i.setDefaults(&settings)), nil
+	return &settings, nil
+}
+
+// Notices returns any problems found while opening the config file.
+// These are not fatal, but the user should be told about them.
+func (c *Config) Notices() []string {
+	return c.notices
 }
 
-// setDefaults only needs to handle "new" things that get added later.
+// setDefaults makes sure maps are not nil and values are usable.
+// Changes to old config files belong in a migration, see migrations.go.
 func (i *Input) setDefaults(s *Settings) *Settings { //nolint:varnamelen
 	s.Name = i.Name
 	s.File = i.File
 	s.LogConfig.Path, _ = homedir.Expand(s.LogConfig.Path)
 
-	if s.Updates == "" {
-		s.Updates = "production"
-	}
-
 	if s.Instances == nil {
 		s.Instances = starrs.Instances{}
 	}
@@ -216,6 +306,10 @@ func (i *Input) setDefaults(s *Settings) *Settings { //nolint:varnamelen
 		s.Hide = make(map[string]bool)
 	}
 
+	if s.Reports == nil {
+		s.Reports = []starrs.Report{}
+	}
+
 	if translations.Languages(language.English.String())[s.Lang] == "" {
 		s.Lang = language.English.String()
 	}
@@ -229,15 +323,16 @@ func (i *Input) setDefaults(s *Settings) *Settings { //nolint:varnamelen
 	return s
 }
 
+// setInstanceDefaults runs when a config file is opened, and when instances are imported.
 func setInstanceDefaults(s *Settings) { //nolint:varnamelen
 	for app := range s.Instances {
-		for idx := range s.Instances[app] {
-			if s.Instance[app] == 0 || s.Instance[app] >= len(s.Instances[app]) {
-				s.Instance[app] = 0
-			}
+		if s.Instance[app] >= len(s.Instances[app]) {
+			s.Instance[app] = 0
+		}
 
+		for idx := range s.Instances[app] {
 			if s.Instances[app][idx].Timeout < 1 {
-				s.Instances[app][idx].Timeout = time.Minute * 2
+				s.Instances[app][idx].Timeout = defaultTimeout
 			}
 		}
 	}
@@ -256,8 +351,15 @@ func (i *Input) findConfigFileFolder() {
 		path = filepath.Dir(filepath.Dir(filepath.Dir(path)))
 	}
 
-	path = filepath.Join(path, i.Name+confExt)
-	if _, err := os.Stat(path); err == nil {
-		i.File = path // file exists next to executable.
+	for _, ext := range []string{jsonExt, confExt} {
+		if file := filepath.Join(path, i.Name+ext); fileExists(file) {
+			i.File = file // file exists next to executable.
+			return
+		}
 	}
 }
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+	"github.com/zalando/go-keyring"
+	"golift.io/starr"
+)
+
+// TestMain keeps encryption keys in memory, so tests never write to the OS keyring.
+func TestMain(m *testing.M) {
+	keyring.MockInit()
+	os.Exit(m.Run())
+}
+
+// testInstances returns instances with secrets, and one without a timeout.
+func testInstances() starrs.Instances {
+	return starrs.Instances{
+		starr.Radarr.String(): {
+			{App: "Radarr", Name: "radarr", URL: "http://radarr:7878", Key: "radarrkey", Timeout: time.Minute},
+			{App: "Radarr", Name: "radarr4k", URL: "http://radarr4k:7878", User: "me", Pass: "secret"},
+		},
+		starr.Sonarr.String(): {
+			{App: "Sonarr", Name: "sonarr", URL: "http://sonarr:8989", Key: "sonarrkey", Timeout: time.Minute},
+		},
+	}
+}
+
+// openTestConfig creates a config file in a temp folder with the test instances.
+func openTestConfig(t *testing.T, name string) *Config {
+	t.Helper()
+
+	config, err := Get(&Input{File: filepath.Join(t.TempDir(), name), Name: "toolbarr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(config.Stop)
+
+	settings := config.Settings()
+	settings.Instances = testInstances()
+
+	if _, err := config.Write(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	return config
+}
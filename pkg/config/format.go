@@ -0,0 +1,187 @@
+package config
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+/* Config files may be gob (.conf) or json (.json). The file extension decides the format. */
+
+// bakExt is appended to a config file when it's replaced by a file in a new format.
+const bakExt = ".bak"
+
+func isJSON(file string) bool {
+	return strings.EqualFold(filepath.Ext(file), jsonExt)
+}
+
+// encodeSettings writes settings using the format that matches the file extension.
+func encodeSettings(file string, writer io.Writer, settings *Settings) error {
+	if !isJSON(file) {
+		return gob.NewEncoder(writer).Encode(settings) //nolint:wrapcheck
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(settings) //nolint:wrapcheck
+}
+
+// decodeSettings reads settings using the format that matches the file extension.
+func decodeSettings(file string, reader io.Reader, settings *Settings) error {
+	if !isJSON(file) {
+		return gob.NewDecoder(reader).Decode(settings) //nolint:wrapcheck
+	}
+
+	return json.NewDecoder(reader).Decode(settings) //nolint:wrapcheck
+}
+
+// convertConfigFile opens a gob config file and writes it to the json file in Input.
+func (i *Input) convertConfigFile(gobFile string) (*Config, error) {
+	jsonFile := i.File
+	i.File = gobFile
+
+	config, err := i.openConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	i.File = jsonFile
+
+	if _, err := config.Convert(jsonExt); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Convert writes the config file in a new format, and uses the new file from now on.
+// Provide the extension for the new format: .conf (gob) or .json.
+// The old file is renamed with a .bak extension.
+func (c *Config) Convert(ext string) (*Settings, error) {
+	if ext != confExt && ext != jsonExt {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, ext)
+	}
+
+	oldFile := c.File()
+	newFile := strings.TrimSuffix(oldFile, filepath.Ext(oldFile)) + ext
+
+	if newFile == oldFile {
+		return c.Settings(), nil
+	}
+
+	c.fileMu.Lock()
+	c.file = newFile
+	c.fileMu.Unlock()
+
+	settings, err := c.Write(nil)
+	if err != nil {
+		c.fileMu.Lock()
+		c.file = oldFile
+		c.fileMu.Unlock()
+
+		return nil, err
+	}
+
+	if err := os.Rename(oldFile, oldFile+bakExt); err != nil {
+		return settings, fmt.Errorf("renaming old config file: %w", err)
+	}
+
+	return settings, nil
+}
+
+// ExportJSON writes the settings as json. Instance passwords and API keys are removed if strip is true.
+// Exported secrets are not encrypted, so they can be imported on another machine.
+func ExportJSON(writer io.Writer, settings *Settings, strip bool) error {
+	settings = settings.copy()
+	settings.File = ""
+
+	if strip {
+		for app := range settings.Instances {
+			for idx := range settings.Instances[app] {
+				settings.Instances[app][idx].Pass = ""
+				settings.Instances[app][idx].Key = ""
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(settings); err != nil {
+		return fmt.Errorf("encoding settings: %w", err)
+	}
+
+	return nil
+}
+
+// ImportJSON reads settings exported with ExportJSON.
+func ImportJSON(reader io.Reader) (*Settings, error) {
+	var settings Settings
+	if err := json.NewDecoder(reader).Decode(&settings); err != nil {
+		return nil, fmt.Errorf("decoding settings: %w", err)
+	}
+
+	if settings.Instances == nil {
+		settings.Instances = starrs.Instances{}
+	}
+
+	return &settings, nil
+}
+
+// MergeInstances adds or updates instances from another config. Instances match on app and name.
+// Empty passwords and API keys do not replace existing values, so stripped exports may be imported.
+// Instance defaults, like the timeout, are applied to every instance.
+func (s *Settings) MergeInstances(instances starrs.Instances) (int, int) {
+	var added, updated int
+
+	for app, list := range instances {
+		for _, instance := range list {
+			instance.App = app
+			idx := findInstance(s.Instances[app], instance.Name)
+
+			if idx < 0 {
+				s.Instances[app] = append(s.Instances[app], instance)
+				added++
+
+				continue
+			}
+
+			existing := s.Instances[app][idx]
+			if instance.Pass == "" {
+				instance.Pass = existing.Pass
+			}
+
+			if instance.Key == "" {
+				instance.Key = existing.Key
+			}
+
+			s.Instances[app][idx] = instance
+			updated++
+		}
+	}
+
+	if s.Instance == nil {
+		s.Instance = make(map[string]int)
+	}
+
+	setInstanceDefaults(s)
+
+	return added, updated
+}
+
+func findInstance(list []starrs.AppConfig, name string) int {
+	for idx := range list {
+		if list[idx].Name == name {
+			return idx
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file string
+		ext  string
+		fail bool
+	}{
+		{file: "toolbarr.conf", ext: jsonExt},
+		{file: "toolbarr.json", ext: confExt},
+		{file: "toolbarr.json", ext: jsonExt},
+		{file: "toolbarr.conf", ext: ".yaml", fail: true},
+	}
+
+	for _, test := range tests {
+		config := openTestConfig(t, test.file)
+		oldFile := config.File()
+
+		settings, err := config.Convert(test.ext)
+		if test.fail {
+			if err == nil {
+				t.Errorf("%s => %s: expected an error", test.file, test.ext)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s => %s: %v", test.file, test.ext, err)
+		}
+
+		if !strings.HasSuffix(config.File(), test.ext) || settings.File != config.File() {
+			t.Errorf("%s => %s: the new file is not in use: %s", test.file, test.ext, config.File())
+		}
+
+		if config.File() != oldFile && !fileExists(oldFile+bakExt) {
+			t.Errorf("%s => %s: the old file was not kept", test.file, test.ext)
+		}
+
+		// The new file decodes in its own format, with the same instances.
+		reopened, err := Get(&Input{File: config.File(), Name: "toolbarr"})
+		if err != nil {
+			t.Fatalf("%s => %s: %v", test.file, test.ext, err)
+		}
+
+		if got := reopened.Settings().Instances; got["Radarr"][1].Pass != "secret" || len(got["Sonarr"]) != 1 {
+			t.Errorf("%s => %s: wrong instances: %+v", test.file, test.ext, got)
+		}
+
+		reopened.Stop()
+	}
+}
+
+func TestConvertOnOpen(t *testing.T) {
+	t.Parallel()
+
+	config := openTestConfig(t, "toolbarr.conf")
+	jsonFile := strings.TrimSuffix(config.File(), confExt) + jsonExt
+
+	// Asking for a json file that does not exist converts the gob file with the same name.
+	converted, err := Get(&Input{File: jsonFile, Name: "toolbarr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer converted.Stop()
+
+	if converted.File() != jsonFile || !fileExists(jsonFile) || len(converted.Settings().Instances["Radarr"]) != 2 {
+		t.Errorf("the gob file was not converted: %s", converted.File())
+	}
+}
+
+func TestExportImportJSON(t *testing.T) {
+	t.Parallel()
+
+	settings := openTestConfig(t, "toolbarr.json").Settings()
+
+	tests := []struct {
+		strip bool
+		pass  string
+	}{
+		{strip: false, pass: "secret"},
+		{strip: true, pass: ""},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := ExportJSON(&buf, settings, test.strip); err != nil {
+			t.Fatal(err)
+		}
+
+		imported, err := ImportJSON(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if imported.File != "" || imported.Instances["Radarr"][1].Pass != test.pass {
+			t.Errorf("strip %v: wrong import: %+v", test.strip, imported)
+		}
+	}
+
+	if settings.Instances["Radarr"][1].Pass != "secret" {
+		t.Error("exporting changed the settings")
+	}
+
+	if imported, err := ImportJSON(strings.NewReader("{}")); err != nil || imported.Instances == nil {
+		t.Errorf("empty imports need instances: %v", err)
+	}
+
+	if _, err := ImportJSON(strings.NewReader("not json")); err == nil {
+		t.Error("bad json should not import")
+	}
+}
+
+func TestMergeInstances(t *testing.T) {
+	t.Parallel()
+
+	settings := &Settings{Instances: testInstances()}
+
+	added, updated := settings.MergeInstances(starrs.Instances{
+		"Radarr": {
+			{Name: "radarr", URL: "http://new:7878"},                     // stripped export: keep the key.
+			{Name: "radarr2", URL: "http://radarr2:7878", Key: "newkey"}, // no timeout.
+		},
+		"Sonarr": {{Name: "sonarr", Key: "replaced", Timeout: time.Second}},
+	})
+	if added != 1 || updated != 2 {
+		t.Errorf("wrong counts: %d added, %d updated", added, updated)
+	}
+
+	tests := []struct {
+		app     string
+		idx     int
+		url     string
+		key     string
+		timeout time.Duration
+	}{
+		{app: "Radarr", idx: 0, url: "http://new:7878", key: "radarrkey", timeout: defaultTimeout},
+		{app: "Radarr", idx: 1, url: "http://radarr4k:7878", timeout: defaultTimeout},
+		{app: "Radarr", idx: 2, url: "http://radarr2:7878", key: "newkey", timeout: defaultTimeout},
+		{app: "Sonarr", idx: 0, key: "replaced", timeout: time.Second},
+	}
+
+	for _, test := range tests {
+		instance := settings.Instances[test.app][test.idx]
+		if instance.App != test.app || instance.URL != test.url || instance.Key != test.key ||
+			instance.Timeout != test.timeout {
+			t.Errorf("%s %d: wrong instance: %+v", test.app, test.idx, instance)
+		}
+	}
+
+	if settings.Instance == nil {
+		t.Error("default instances need a map")
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* Settings schema migrations. Old config files are upgraded one version at a time when opened. */
+
+// migration upgrades settings from the previous schema version to Version.
+type migration struct {
+	Version int
+	Desc    string
+	Migrate func(*Settings)
+}
+
+// migrations is the ordered list of schema changes. Never remove or reorder entries.
+// To change Settings or AppConfig: add a field, then append a migration that fills it in.
+//
+//nolint:gochecknoglobals
+var migrations = []migration{
+	{Version: 1, Desc: "default update channel", Migrate: migrateV1},
+}
+
+// SchemaVersion is the current settings schema version.
+//
+//nolint:gochecknoglobals
+var SchemaVersion = migrations[len(migrations)-1].Version
+
+// migrateSettings upgrades settings to the current schema version.
+// A copy of the source file the settings were read from (the config file, or a backup) is saved before
+// it's migrated. Returns true if the settings changed and need to be written.
+func migrateSettings(file, source string, settings *Settings) (bool, error) {
+	if settings.SchemaVersion >= SchemaVersion {
+		return false, nil
+	}
+
+	if err := backupBeforeMigration(file, source, settings.SchemaVersion); err != nil {
+		return false, err
+	}
+
+	for _, step := range migrations {
+		if step.Version <= settings.SchemaVersion {
+			continue
+		}
+
+		step.Migrate(settings)
+		settings.SchemaVersion = step.Version
+	}
+
+	return true, nil
+}
+
+// backupBeforeMigration copies the source of a config file before it's migrated.
+// ie. toolbarr.conf => toolbarr.schema0.conf.
+func backupBeforeMigration(file, source string, version int) error {
+	data, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading config file for migration backup: %s: %w", source, err)
+	}
+
+	ext := filepath.Ext(file)
+	backup := strings.TrimSuffix(file, ext) + ".schema" + strconv.Itoa(version) + ext
+
+	if err := os.WriteFile(backup, data, 0o600); err != nil { //nolint:gomnd
+		return fmt.Errorf("writing config file migration backup: %s: %w", backup, err)
+	}
+
+	return nil
+}
+
+// migrateV1 handles config files from before schema versions existed.
+// Instance timeouts are not migrated; setInstanceDefaults fixes those every time instances are loaded.
+func migrateV1(settings *Settings) {
+	if settings.Updates == "" {
+		settings.Updates = "production"
+	}
+}
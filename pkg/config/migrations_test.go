@@ -0,0 +1,91 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateV1(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		updates string
+		want    string
+	}{
+		{updates: "", want: "production"},
+		{updates: "unstable", want: "unstable"},
+		{updates: "production", want: "production"},
+	}
+
+	for _, test := range tests {
+		settings := &Settings{Updates: test.updates}
+		if migrateV1(settings); settings.Updates != test.want {
+			t.Errorf("%q: wrong update channel: %q, expected %q", test.updates, settings.Updates, test.want)
+		}
+	}
+}
+
+func TestMigrateSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version  int
+		migrated bool
+	}{
+		{version: 0, migrated: true},
+		{version: SchemaVersion, migrated: false},
+		{version: SchemaVersion + 1, migrated: false}, // from a newer toolbarr; left alone.
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "toolbarr.json")
+		if err := writeFile(file, &Settings{SchemaVersion: test.version}); err != nil {
+			t.Fatal(err)
+		}
+
+		settings := &Settings{SchemaVersion: test.version}
+
+		migrated, err := migrateSettings(file, file, settings)
+		if err != nil || migrated != test.migrated {
+			t.Errorf("version %d: wrong result: %v, %v", test.version, migrated, err)
+		}
+
+		backup := strings.TrimSuffix(file, jsonExt) + ".schema0" + jsonExt
+		if fileExists(backup) != (test.version == 0) {
+			t.Errorf("version %d: the file should be saved before it's migrated, and only then", test.version)
+		}
+
+		if test.migrated && (settings.SchemaVersion != SchemaVersion || settings.Updates != "production") {
+			t.Errorf("version %d: not migrated: %+v", test.version, settings)
+		}
+	}
+}
+
+func TestOpenOldConfig(t *testing.T) {
+	t.Parallel()
+
+	// A config file from before schema versions, with plain text secrets and no timeouts.
+	file := filepath.Join(t.TempDir(), "toolbarr.conf")
+	if err := writeFile(file, &Settings{Instances: testInstances()}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Get(&Input{File: file, Name: "toolbarr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer config.Stop()
+
+	if settings := config.Settings(); settings.SchemaVersion != SchemaVersion ||
+		settings.Instances["Radarr"][1].Timeout != defaultTimeout || settings.Instances["Radarr"][1].Pass != "secret" {
+		t.Errorf("the old config was not upgraded: %+v", settings)
+	}
+
+	// The upgraded file is written with encrypted secrets.
+	written, err := readSettings(file)
+	if err != nil || written.SchemaVersion != SchemaVersion ||
+		!strings.HasPrefix(written.Instances["Radarr"][1].Pass, encPrefix) {
+		t.Errorf("the upgraded config was not written: %+v, %v", written, err)
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+	"github.com/zalando/go-keyring"
+)
+
+/* Instance passwords and API keys are encrypted before they are written to the config file. */
+
+const (
+	// encPrefix identifies an encrypted value in the config file.
+	encPrefix = "enc:v1:"
+	// keySize is the length of the AES-256 key used to encrypt secrets.
+	keySize = 32
+	// keyExt is appended to the config file path to store the key when the OS keyring is not available.
+	keyExt = ".key"
+)
+
+var ErrInvalidSecret = errors.New("encrypted value is too short")
+
+// cipherKey returns the key used to encrypt secrets in the config file.
+// The key is stored in the OS keyring (Secret Service on Linux) using the config file path,
+// without extension, as the user. Headless systems without a keyring get a key file next to the config file.
+// If the key is lost, encrypted secrets cannot be decrypted and must be entered again.
+func (c *Config) cipherKey() ([]byte, error) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+
+	if c.key != nil {
+		return c.key, nil
+	}
+
+	// The key is loaded when a config file with secrets is opened, so this file has none to lose.
+	key, err := getKey(c.File(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.key = key
+
+	return key, nil
+}
+
+// getKey reads the encryption key, or creates one if there is none. A key is only created when the keyring
+// does not have one. If the keyring fails another way, like when it's locked, and the config file has
+// encrypted secrets, an error is returned; a new key would make those secrets unreadable.
+func getKey(configFile string, encrypted bool) ([]byte, error) {
+	// The extension is removed so the key survives converting the config file format.
+	configFile = strings.TrimSuffix(configFile, filepath.Ext(configFile))
+	keyFile := configFile + keyExt
+	// A key file means this system had no keyring when the key was created.
+	if data, err := os.ReadFile(keyFile); err == nil {
+		return decodeKey(strings.TrimSpace(string(data)))
+	}
+
+	secret, err := keyring.Get(mnd.Title, configFile)
+	if err == nil {
+		return decodeKey(secret)
+	} else if encrypted && !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("reading encryption key from the keyring: %w", err)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating encryption key: %w", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if err := keyring.Set(mnd.Title, configFile, encoded); err == nil {
+		return key, nil
+	}
+
+	if err := os.WriteFile(keyFile, []byte(encoded), mnd.Mode0600); err != nil {
+		return nil, fmt.Errorf("writing encryption key file: %w", err)
+	}
+
+	return key, nil
+}
+
+// hasEncrypted returns true if any instance password or api key is encrypted.
+func hasEncrypted(instances starrs.Instances) bool {
+	for app := range instances {
+		for _, instance := range instances[app] {
+			if strings.HasPrefix(instance.Pass, encPrefix) || strings.HasPrefix(instance.Key, encPrefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func decodeKey(secret string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("decoding encryption key: %w", err)
+	}
+
+	if len(key) != keySize {
+		return nil, fmt.Errorf("%w: encryption key has wrong size: %d", ErrInvalidSecret, len(key))
+	}
+
+	return key, nil
+}
+
+// encryptSecrets encrypts every instance password and api key in place.
+func encryptSecrets(key []byte, instances starrs.Instances) error {
+	for app := range instances {
+		for idx := range instances[app] {
+			instance := &instances[app][idx]
+
+			var err error
+			if instance.Pass, err = encrypt(key, instance.Pass); err != nil {
+				return err
+			}
+
+			if instance.Key, err = encrypt(key, instance.Key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decryptSecrets decrypts every instance password and api key in place.
+// Returns the number of secrets that were not encrypted. Those came from an older config file.
+// Secrets that fail to decrypt are left encrypted, so they are not lost if the right key comes back.
+func decryptSecrets(key []byte, instances starrs.Instances) (int, error) {
+	var (
+		plain int
+		errs  []error
+	)
+
+	decryptValue := func(value *string) {
+		if *value == "" {
+			return
+		}
+
+		if !strings.HasPrefix(*value, encPrefix) {
+			plain++
+			return
+		}
+
+		decrypted, err := decrypt(key, *value)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+
+		*value = decrypted
+	}
+
+	for app := range instances {
+		for idx := range instances[app] {
+			decryptValue(&instances[app][idx].Pass)
+			decryptValue(&instances[app][idx].Key)
+		}
+	}
+
+	return plain, errors.Join(errs...)
+}
+
+func encrypt(key []byte, value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, encPrefix) {
+		return value, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return encPrefix + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), nil)), nil
+}
+
+func decrypt(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil {
+		return "", fmt.Errorf("decoding secret: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", ErrInvalidSecret
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypting secret: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating gcm: %w", err)
+	}
+
+	return gcm, nil
+}
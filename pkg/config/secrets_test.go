@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestSecretsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{1}, keySize)
+	instances := testInstances()
+
+	if err := encryptSecrets(key, instances); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: instances["Radarr"][0].Key, want: "radarrkey"},
+		{value: instances["Radarr"][0].Pass, want: ""},
+		{value: instances["Radarr"][1].Pass, want: "secret"},
+		{value: instances["Sonarr"][0].Key, want: "sonarrkey"},
+	}
+
+	for _, test := range tests {
+		if test.want != "" && !strings.HasPrefix(test.value, encPrefix) {
+			t.Errorf("%s was not encrypted: %s", test.want, test.value)
+		} else if test.want == "" && test.value != "" {
+			t.Errorf("empty values stay empty: %s", test.value)
+		}
+	}
+
+	// Encrypting twice does not change encrypted values.
+	again := instances.Copy()
+	if err := encryptSecrets(key, again); err != nil || again["Radarr"][1].Pass != instances["Radarr"][1].Pass {
+		t.Errorf("an encrypted value was encrypted again: %v", err)
+	}
+
+	plain, err := decryptSecrets(key, instances)
+	if err != nil || plain != 0 {
+		t.Fatalf("decrypting: %d plain values, %v", plain, err)
+	}
+
+	for app, list := range testInstances() {
+		for idx, instance := range list {
+			if instances[app][idx] != instance {
+				t.Errorf("%s did not survive the round trip: %+v", instance.Name, instances[app][idx])
+			}
+		}
+	}
+
+	// Plain text values from older config files are counted, so the file is written again.
+	if plain, err = decryptSecrets(key, testInstances()); err != nil || plain != 3 {
+		t.Errorf("expected 3 plain values: %d, %v", plain, err)
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	t.Parallel()
+
+	instances := testInstances()
+	if err := encryptSecrets(bytes.Repeat([]byte{1}, keySize), instances); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := instances.Copy()
+
+	if _, err := decryptSecrets(bytes.Repeat([]byte{2}, keySize), instances); err == nil {
+		t.Fatal("the wrong key should not decrypt secrets")
+	}
+
+	// The secrets stay encrypted, so the right key may still decrypt them.
+	if instances["Radarr"][1].Pass != encrypted["Radarr"][1].Pass ||
+		instances["Sonarr"][0].Key != encrypted["Sonarr"][0].Key {
+		t.Errorf("secrets that fail to decrypt must not change: %+v", instances)
+	}
+
+	for _, value := range []string{encPrefix + "!!!", encPrefix + "AAAA"} {
+		if _, err := decrypt(bytes.Repeat([]byte{1}, keySize), value); err == nil {
+			t.Errorf("%s is not a valid secret", value)
+		}
+	}
+}
+
+// TestGetKey changes the keyring provider, so it does not run in parallel.
+func TestGetKey(t *testing.T) { //nolint:paralleltest
+	defer keyring.MockInit()
+
+	errLocked := errors.New("keyring is locked") //nolint:goerr113
+	tests := []struct {
+		desc      string
+		keyring   error // nil is a working keyring.
+		encrypted bool
+		keyFile   bool // a key file is written.
+		fail      bool
+	}{
+		{desc: "new key in the keyring"},
+		{desc: "new key with encrypted secrets", encrypted: true},
+		{desc: "no keyring", keyring: errLocked, keyFile: true},
+		{desc: "locked keyring with encrypted secrets", keyring: errLocked, encrypted: true, fail: true},
+		{desc: "no keyring entry with encrypted secrets", keyring: keyring.ErrNotFound, encrypted: true, keyFile: true},
+	}
+
+	for _, test := range tests {
+		if keyring.MockInit(); test.keyring != nil {
+			keyring.MockInitWithError(test.keyring)
+		}
+
+		file := filepath.Join(t.TempDir(), "toolbarr.conf")
+
+		key, err := getKey(file, test.encrypted)
+		if (err != nil) != test.fail {
+			t.Errorf("%s: wrong error: %v", test.desc, err)
+			continue
+		} else if test.fail {
+			continue
+		}
+
+		if _, err := os.Stat(strings.TrimSuffix(file, ".conf") + keyExt); (err == nil) != test.keyFile {
+			t.Errorf("%s: key file written: %v, expected %v", test.desc, err == nil, test.keyFile)
+		}
+
+		// The same key comes back, from the keyring or the key file, for either config file format.
+		if again, err := getKey(strings.TrimSuffix(file, ".conf")+jsonExt, true); err != nil || !bytes.Equal(key, again) {
+			t.Errorf("%s: a different key came back: %v", test.desc, err)
+		}
+	}
+}
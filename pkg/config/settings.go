@@ -1,9 +1,7 @@
 package config
 
 import (
-	"encoding/gob"
 	"fmt"
-	"os"
 
 	"github.com/Notifiarr/toolbarr/pkg/logs"
 	"github.com/Notifiarr/toolbarr/pkg/starrs"
@@ -20,6 +18,10 @@ type Settings struct {
 	Instances starrs.Instances
 	Default
 	Hide map[string]bool
+	// Reports are the user's saved queries for the SQL explorer.
+	Reports []starrs.Report
+	// SchemaVersion is the version of these settings. Old config files are migrated when opened.
+	SchemaVersion int
 }
 
 // Default holds items that can have default values.
@@ -62,21 +64,35 @@ func (c *Config) Write(settings *Settings) (*Settings, error) {
 		settings = c.Settings()
 	}
 
-	settings.File = c.file
+	file := c.File()
+	settings.File = file
 
-	cnfOpen, err := os.Create(c.file)
+	key, err := c.cipherKey()
 	if err != nil {
-		return nil, fmt.Errorf("creating config file: %s: %w", c.file, err)
+		return nil, fmt.Errorf("encrypting config file: %s: %w", file, err)
 	}
-	defer cnfOpen.Close()
 
-	if err = gob.NewEncoder(cnfOpen).Encode(settings); err != nil {
-		return nil, fmt.Errorf("encoding config file: %s: %w", c.file, err)
+	// Encrypt a copy, so the running settings keep plain text secrets.
+	encrypted := settings.copy()
+	if err = encryptSecrets(key, encrypted.Instances); err != nil {
+		return nil, fmt.Errorf("encrypting config file: %s: %w", file, err)
+	}
+
+	if err = c.writeFile(file, encrypted); err != nil {
+		return nil, err
 	}
 
 	return c.Update(settings), nil
 }
 
+// File returns the path to the config file.
+func (c *Config) File() string {
+	c.fileMu.RLock()
+	defer c.fileMu.RUnlock()
+
+	return c.file
+}
+
 // watch for updates and settings requests.
 // runs in a go routine and holds the running config.
 func (c *Config) watch() {
@@ -88,7 +104,7 @@ func (c *Config) watch() {
 	for q := range c.ask {
 		if q != nil {
 			// new settings, replace running values.
-			q.File, c.settings = c.file, q
+			q.File, c.settings = c.File(), q
 		}
 		// send a copy of running values.
 		c.rep <- c.settings.copy()
@@ -100,6 +116,12 @@ func (s *Settings) copy() *Settings {
 	settings.Hide = make(map[string]bool)
 	settings.Instance = make(map[string]int)
 	settings.Instances = s.Instances.Copy()
+	settings.Reports = make([]starrs.Report, len(s.Reports))
+
+	for idx, report := range s.Reports {
+		settings.Reports[idx] = report
+		settings.Reports[idx].Params = append([]starrs.ReportParam{}, report.Params...)
+	}
 
 	for k, v := range s.Hide {
 		settings.Hide[k] = v
@@ -3,6 +3,7 @@ package logs
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -86,6 +87,28 @@ func New() *Logger {
 	return logger
 }
 
+// SetupCLI sends logs to stderr for command line use, so stdout only contains command output.
+// Debug and trace logs are discarded unless the level allows them. This does not touch the wails runtime.
+func (l *Logger) SetupCLI(config LogConfig, level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	config.Level = level
+	l.config = &config
+	l.printer = message.NewPrinter(message.MatchLanguage(language.English.String(), config.Lang))
+	l.logger.SetOutput(os.Stderr)
+	l.debug.SetOutput(io.Discard)
+	l.trace.SetOutput(io.Discard)
+
+	if level >= LogLevelDebug {
+		l.debug.SetOutput(os.Stderr)
+	}
+
+	if level >= LogLevelTrace {
+		l.trace.SetOutput(os.Stderr)
+	}
+}
+
 // SetupLogging splits log writers into a file and/or stdout.
 // Config is optional, but must be provided here or with New().
 func (l *Logger) Setup(ctx context.Context, config LogConfig) {
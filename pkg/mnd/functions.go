@@ -1,7 +1,11 @@
 //nolint:gomnd
 package mnd
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
 
 // FormatBytes converts a byte counter into a pretty UI string.
 // The input val must be int, int64, uint64 or float64.
@@ -38,3 +42,32 @@ func FormatBytes(size interface{}) string { //nolint:cyclop
 		return fmt.Sprintf("%.0f B", val)
 	}
 }
+
+// WriteFile writes data to a temp file next to file, syncs it to disk, and renames it over file.
+// A crash or full disk while writing leaves the existing file untouched. The file gets mode 0600.
+func WriteFile(file string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name()) // does nothing after a successful rename.
+	defer tmpFile.Close()
+
+	if _, err = tmpFile.Write(data); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	if err = tmpFile.Sync(); err != nil {
+		return fmt.Errorf("syncing temp file: %w", err)
+	}
+
+	if err = tmpFile.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err = os.Rename(tmpFile.Name(), file); err != nil {
+		return fmt.Errorf("replacing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package mnd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Headless is an App that never shows a window. Use it from a cli, an http api or tests.
+// Questions get the same Answer every time, and file pickers return the configured paths.
+type Headless struct {
+	// Answer is returned for every question, except questions that stop a starr app.
+	Answer bool
+	// Stop allows stopping starr apps. Nothing starts them again, so Answer does not allow it.
+	Stop bool
+	// OpenPath is returned by OpenFile. ErrNoDialog is returned if this is empty.
+	OpenPath string
+	// SavePath is returned by SaveFile. ErrNoDialog is returned if this is empty.
+	SavePath string
+	// Output receives questions and errors. Optional.
+	Output io.Writer
+	// OnEmit receives events. Optional.
+	OnEmit func(event string, data any)
+}
+
+var (
+	_ App     = (*Headless)(nil)
+	_ Stopper = (*Headless)(nil)
+)
+
+// Ask writes the question to Output and returns Answer.
+func (h *Headless) Ask(title, msg string) bool {
+	if h.Output != nil {
+		fmt.Fprintf(h.Output, "%s: %s\n", title, strings.TrimSpace(msg))
+	}
+
+	return h.Answer
+}
+
+// StopApps returns Stop.
+func (h *Headless) StopApps() bool {
+	return h.Stop
+}
+
+// Emit passes the event to OnEmit.
+func (h *Headless) Emit(event string, data any) {
+	if h.OnEmit != nil {
+		h.OnEmit(event, data)
+	}
+}
+
+// OpenFile returns OpenPath.
+func (h *Headless) OpenFile(_ *FileDialog) (string, error) {
+	if h.OpenPath == "" {
+		return "", ErrNoDialog
+	}
+
+	return h.OpenPath, nil
+}
+
+// SaveFile returns SavePath.
+func (h *Headless) SaveFile(_ *FileDialog) (string, error) {
+	if h.SavePath == "" {
+		return "", ErrNoDialog
+	}
+
+	return h.SavePath, nil
+}
+
+// LogError writes the error to Output.
+func (h *Headless) LogError(msg string) {
+	if h.Output != nil {
+		fmt.Fprintln(h.Output, "ERROR:", msg)
+	}
+}
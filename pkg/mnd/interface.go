@@ -1,6 +1,47 @@
 package mnd
 
-// App provides a simple interface to do things in the wails app.
+import "errors"
+
+// ErrNoDialog is returned by a host that cannot show a file picker, and has no file to use.
+var ErrNoDialog = errors.New("no file dialog available; provide a file path")
+
+// App provides a simple interface to do things in the host: the wails app, a cli or a test.
+// The starrs package uses this to talk to the user, so it never calls the wails runtime.
 type App interface {
+	// Ask a Yes/No question. Returns true for yes.
 	Ask(title string, msg string) bool
+	// Emit sends an event to the frontend. Used for progress bars.
+	Emit(event string, data any)
+	// OpenFile asks for a file to read. An empty path means the user canceled.
+	OpenFile(dialog *FileDialog) (string, error)
+	// SaveFile asks for a file to write. An empty path means the user canceled.
+	SaveFile(dialog *FileDialog) (string, error)
+	// LogError reports an error to the host's log.
+	LogError(msg string)
+}
+
+// Stopper is implemented by hosts without a person to ask before a starr app is stopped.
+// StopApps returns true if stopping apps was allowed up front, like with a cli flag.
+type Stopper interface {
+	StopApps() bool
+}
+
+// FileDialog is the input for a file picker.
+type FileDialog struct {
+	Title     string
+	Directory string // Start in this folder.
+	Filename  string // Suggested file name.
+	Hidden    bool   // Show hidden files.
+	Filters   []FileFilter
+}
+
+// FileFilter limits the files a picker displays.
+type FileFilter struct {
+	DisplayName string // JSON (*.json)
+	Pattern     string // *.json
+}
+
+// JSONFilter only displays json files.
+func JSONFilter() []FileFilter {
+	return []FileFilter{{DisplayName: "JSON (*.json)", Pattern: "*.json"}}
 }
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+/* Bound methods are called the same way the wails frontend calls them: go.<package>.<struct>.<method>(args...) */
+
+var (
+	ErrUnknownMethod = errors.New("unknown method")
+	ErrDesktopOnly   = errors.New("this method only works in the desktop app")
+	ErrArguments     = errors.New("wrong number of arguments")
+)
+
+// desktopOnly are bound methods that need a window, pick files on this computer, or change the local machine.
+// They are not available over http. A browser cannot pick or save files on the server, so the import and
+// export methods that use a file dialog are here too.
+//
+//nolint:gochecknoglobals
+var desktopOnly = map[string]bool{
+	"Startup":         true,
+	"StartHeadless":   true,
+	"Quit":            true,
+	"CreateShortcut":  true,
+	"DownloadUpdate":  true,
+	"LaunchInstaller": true,
+	"OpenFolder":      true,
+	"DeleteUnmapped":  true,
+	// File dialogs.
+	"PickFile":              true,
+	"PickFolder":            true,
+	"ExportSettings":        true,
+	"ImportSettings":        true,
+	"ExportQuery":           true,
+	"ExportDownloadClients": true,
+	"ImportDownloadClients": true,
+	"ExportExclusions":      true,
+	"ImportExclusions":      true,
+	"ExportImportLists":     true,
+	"ImportImportLists":     true,
+	"ExportIndexer":         true,
+	"ImportIndexer":         true,
+	"ExportQualityProfiles": true,
+	"ImportQualityProfiles": true,
+}
+
+// binding is a method that may be called over http.
+type binding struct {
+	method reflect.Value
+}
+
+// bindings maps "package.Struct.Method" to a callable method.
+type bindings map[string]*binding
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem() //nolint:gochecknoglobals
+
+// bind finds the exported methods on each object. The names match the wailsjs bindings.
+func bind(objects ...any) bindings {
+	bound := make(bindings)
+
+	for _, obj := range objects {
+		value := reflect.ValueOf(obj)
+		elem := value.Type()
+
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+
+		prefix := path.Base(elem.PkgPath()) + "." + elem.Name() + "."
+
+		for idx := range value.NumMethod() {
+			name := value.Type().Method(idx).Name
+			if !desktopOnly[name] {
+				bound[prefix+name] = &binding{method: value.Method(idx)}
+			}
+		}
+	}
+
+	return bound
+}
+
+// call runs a bound method. The arguments are a json array, like the wails ipc payload.
+// Missing trailing arguments get their zero value.
+func (b bindings) call(name string, body []byte) (any, error) {
+	bound := b[name]
+	if bound == nil {
+		if _, method, _ := cutLast(name); desktopOnly[method] {
+			return nil, fmt.Errorf("%w: %s", ErrDesktopOnly, name)
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, name)
+	}
+
+	var raw []json.RawMessage
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("decoding arguments: %w", err)
+		}
+	}
+
+	mType := bound.method.Type()
+	if len(raw) > mType.NumIn() {
+		return nil, fmt.Errorf("%w: %s takes %d, got %d", ErrArguments, name, mType.NumIn(), len(raw))
+	}
+
+	args := make([]reflect.Value, mType.NumIn())
+
+	for idx := range args {
+		arg := reflect.New(mType.In(idx))
+		if idx < len(raw) {
+			if err := json.Unmarshal(raw[idx], arg.Interface()); err != nil {
+				return nil, fmt.Errorf("decoding argument %d: %w", idx+1, err)
+			}
+		}
+
+		args[idx] = arg.Elem()
+	}
+
+	return output(bound.method.Call(args))
+}
+
+// output turns the return values into a single value and an error, like wails does.
+func output(values []reflect.Value) (any, error) {
+	if len(values) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	last := values[len(values)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			return nil, last.Interface().(error) //nolint:forcetypeassert
+		}
+
+		values = values[:len(values)-1]
+	}
+
+	if len(values) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return values[0].Interface(), nil
+}
+
+// cutLast splits a name at the last dot.
+func cutLast(name string) (string, string, bool) {
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		return name, "", false
+	}
+
+	return name[:idx], name[idx+1:], true
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+// bindTest has methods with the return values bound methods may have.
+type bindTest struct{}
+
+type bindInput struct {
+	Name string
+	Size int
+}
+
+var errBindTest = errors.New("bind test error")
+
+func (bindTest) Echo(input *bindInput, count int) (*bindInput, error) {
+	if input == nil {
+		return nil, errBindTest
+	}
+
+	input.Size += count
+
+	return input, nil
+}
+
+func (bindTest) Nothing() {}
+
+func (bindTest) Quit() string { return "quit" }
+
+func TestBindCall(t *testing.T) {
+	t.Parallel()
+
+	bound := bind(bindTest{})
+
+	tests := []struct {
+		name string
+		body string
+		want any
+		err  error
+	}{
+		{name: "server.bindTest.Echo", body: `[{"Name":"a","Size":1}, 2]`, want: &bindInput{Name: "a", Size: 3}},
+		{name: "server.bindTest.Echo", body: `[{"Name":"a"}]`, want: &bindInput{Name: "a"}}, // missing args are zero.
+		{name: "server.bindTest.Echo", body: ``, err: errBindTest},
+		{name: "server.bindTest.Echo", body: `[null, 1, 2]`, err: ErrArguments},
+		{name: "server.bindTest.Nothing", body: `[]`},
+		{name: "server.bindTest.Missing", body: `[]`, err: ErrUnknownMethod},
+		{name: "server.bindTest.Quit", body: `[]`, err: ErrDesktopOnly},
+	}
+
+	for _, test := range tests {
+		got, err := bound.call(test.name, []byte(test.body))
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s(%s): wrong error: %v, expected %v", test.name, test.body, err, test.err)
+		} else if want, ok := test.want.(*bindInput); ok && *got.(*bindInput) != *want { //nolint:forcetypeassert
+			t.Errorf("%s(%s): wrong result: %+v, expected %+v", test.name, test.body, got, want)
+		} else if test.want == nil && got != nil {
+			t.Errorf("%s(%s): expected no result, got %v", test.name, test.body, got)
+		}
+	}
+
+	if _, err := bound.call("server.bindTest.Echo", []byte(`{"not": "an array"}`)); err == nil {
+		t.Error("arguments that are not an array should return an error")
+	}
+
+	if _, err := bound.call("server.bindTest.Echo", []byte(`["not an object"]`)); err == nil {
+		t.Error("arguments of the wrong type should return an error")
+	}
+}
+
+// TestDesktopOnlyNames makes sure every desktop only method exists and is not bound, so a rename does not expose it.
+func TestDesktopOnlyNames(t *testing.T) {
+	t.Parallel()
+
+	objects := []any{&app.App{}, &starrs.Starrs{}}
+
+	for name := range bind(objects...) {
+		if _, method, _ := cutLast(name); desktopOnly[method] {
+			t.Errorf("%s is desktop only, and should not be bound", name)
+		}
+	}
+
+	for name := range desktopOnly {
+		found := false
+
+		for _, object := range objects {
+			if _, ok := reflect.TypeOf(object).MethodByName(name); ok {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("desktop only method %s does not exist", name)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+)
+
+/* Events go to every connected browser over server sent events. Questions wait for an answer from one. */
+
+// Events the browser shim handles itself. Everything else is passed to EventsOn listeners.
+const (
+	askEvent   = "toolbarr:ask"
+	errorEvent = "toolbarr:error"
+)
+
+// askTimeout is how long a question waits for an answer before it's answered with no.
+const askTimeout = 5 * time.Minute
+
+// event is sent to the browser as json.
+type event struct {
+	Name string `json:"name"`
+	Data []any  `json:"data"`
+}
+
+// question is sent to the browser with an ask event.
+type question struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Msg   string `json:"msg"`
+}
+
+// host implements app.Host for browsers.
+type host struct {
+	ctx     context.Context
+	log     *logs.Logger
+	level   logs.Level
+	mu      sync.Mutex
+	clients map[chan *event]struct{}
+	answers map[string]chan bool
+	counter atomic.Int64
+}
+
+var _ app.Host = (*host)(nil)
+
+func newHost(ctx context.Context, log *logs.Logger, level logs.Level) *host {
+	return &host{
+		ctx:     ctx,
+		log:     log,
+		level:   level,
+		clients: make(map[chan *event]struct{}),
+		answers: make(map[string]chan bool),
+	}
+}
+
+// subscribe returns a channel that gets every event. Call the returned function when done.
+func (h *host) subscribe() (chan *event, func()) {
+	events := make(chan *event, 100) //nolint:gomnd
+
+	h.mu.Lock()
+	h.clients[events] = struct{}{}
+	h.mu.Unlock()
+
+	return events, func() {
+		h.mu.Lock()
+		delete(h.clients, events)
+		h.mu.Unlock()
+	}
+}
+
+// send an event to every browser. Slow browsers miss events; they're mostly progress updates.
+func (h *host) send(name string, data ...any) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		select {
+		case client <- &event{Name: name, Data: data}:
+		default:
+		}
+	}
+
+	return len(h.clients)
+}
+
+// Emit sends an event to the browsers.
+func (h *host) Emit(name string, data any) {
+	h.send(name, data)
+}
+
+// Ask sends a question to the browsers and waits for the first answer.
+// The answer is no if nobody is connected, or nobody answers in time.
+func (h *host) Ask(title, msg string) bool {
+	id := strconv.FormatInt(h.counter.Add(1), mnd.Base10)
+	answer := make(chan bool, 1)
+
+	h.mu.Lock()
+	h.answers[id] = answer
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.answers, id)
+		h.mu.Unlock()
+	}()
+
+	if h.send(askEvent, &question{ID: id, Title: title, Msg: msg}) == 0 {
+		h.log.Warnf("No browser connected to answer question: %s", title)
+		return false
+	}
+
+	select {
+	case yes := <-answer:
+		return yes
+	case <-time.After(askTimeout):
+		h.log.Warnf("No answer received for question: %s", title)
+		return false
+	case <-h.ctx.Done():
+		return false
+	}
+}
+
+// answer a question. Returns false if the question is not waiting.
+func (h *host) answer(id string, yes bool) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	answer := h.answers[id]
+	if answer == nil {
+		return false
+	}
+
+	select {
+	case answer <- yes:
+		return true
+	default:
+		return false // already answered.
+	}
+}
+
+// ErrorDialog sends an error to the browsers.
+func (h *host) ErrorDialog(title, msg string) {
+	h.log.Errorf("%s: %s", title, msg)
+	h.send(errorEvent, map[string]string{"title": title, "msg": msg})
+}
+
+// OpenFile is not possible; the browser is not on the same machine.
+func (h *host) OpenFile(_ *mnd.FileDialog) (string, error) {
+	return "", mnd.ErrNoDialog
+}
+
+// SaveFile is not possible; the browser is not on the same machine.
+func (h *host) SaveFile(_ *mnd.FileDialog) (string, error) {
+	return "", mnd.ErrNoDialog
+}
+
+// OpenDirectory is not possible; the browser is not on the same machine.
+func (h *host) OpenDirectory(_ *mnd.FileDialog) (string, error) {
+	return "", mnd.ErrNoDialog
+}
+
+// SetupLogs applies a changed log config. There is no wails runtime, so logs stay on stderr.
+func (h *host) SetupLogs(config logs.LogConfig) {
+	h.log.SetupCLI(config, h.level)
+}
+
+// LogError writes an error to the log.
+func (h *host) LogError(msg string) {
+	h.log.Errorf("%s", msg)
+}
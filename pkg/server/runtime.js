@@ -0,0 +1,85 @@
+// Replaces the wails runtime and ipc in a browser. Bound Go methods become http calls,
+// and events arrive over server sent events. Served from /wails/runtime.js in server mode.
+(function () {
+  const listeners = {};
+
+  async function call(name, args) {
+    const resp = await fetch("/api/call/" + name, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(args),
+    });
+    const body = await resp.json();
+    if (!resp.ok) throw body.error;
+    return body.result;
+  }
+
+  // window.go.app.App.Method(args) => POST /api/call/app.App.Method
+  const proxy = (path) => new Proxy(function () {}, {
+    get: (_, key) => proxy(path.concat(key)),
+    apply: (_, __, args) => call(path.join("."), args),
+  });
+  window.go = proxy([]);
+
+  function on(name, callback, max) {
+    const listener = { callback, max: max || -1 };
+    (listeners[name] = listeners[name] || []).push(listener);
+    return () => { listeners[name] = (listeners[name] || []).filter(l => l !== listener) };
+  }
+
+  function emit(name, data) {
+    (listeners[name] || []).slice().forEach(listener => {
+      listener.callback(...data);
+      if (listener.max > 0 && --listener.max === 0) {
+        listeners[name] = listeners[name].filter(l => l !== listener);
+      }
+    });
+  }
+
+  function answer(q) {
+    const yes = window.confirm(q.title + "\n\n" + q.msg);
+    fetch("/api/answer/" + q.id, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(yes),
+    });
+  }
+
+  const source = new EventSource("/api/events");
+  source.onmessage = (msg) => {
+    const ev = JSON.parse(msg.data);
+    if (ev.name === "toolbarr:ask") answer(ev.data[0]);
+    else if (ev.name === "toolbarr:error") window.alert(ev.data[0].title + "\n\n" + ev.data[0].msg);
+    else emit(ev.name, ev.data || []);
+  };
+
+  const noop = () => {};
+  window.runtime = {
+    EventsOnMultiple: on,
+    EventsOn: (name, callback) => on(name, callback, -1),
+    EventsOnce: (name, callback) => on(name, callback, 1),
+    EventsOff: (name, ...more) => [name, ...more].forEach(n => delete listeners[n]),
+    EventsEmit: (name, ...data) => emit(name, data),
+    LogPrint: console.log, LogTrace: console.debug, LogDebug: console.debug, LogInfo: console.info,
+    LogWarning: console.warn, LogError: console.error, LogFatal: console.error,
+    BrowserOpenURL: (url) => window.open(url, "_blank", "noopener"),
+    Environment: async () => ({ buildType: "server", platform: "web", arch: "" }),
+    WindowReload: () => window.location.reload(),
+    WindowReloadApp: () => window.location.reload(),
+    WindowSetTitle: (title) => { document.title = title },
+    WindowIsFullscreen: async () => false, WindowIsMaximised: async () => false,
+    WindowIsMinimised: async () => false, WindowIsNormal: async () => true,
+    WindowGetSize: async () => ({ w: window.innerWidth, h: window.innerHeight }),
+    WindowGetPosition: async () => ({ x: 0, y: 0 }),
+    ScreenGetAll: async () => [],
+    ClipboardGetText: () => navigator.clipboard.readText(),
+    ClipboardSetText: (text) => navigator.clipboard.writeText(text).then(() => true),
+    Quit: noop, Hide: noop, Show: noop,
+  };
+  // Every other window method does nothing in a browser.
+  ["WindowSetAlwaysOnTop", "WindowSetSystemDefaultTheme", "WindowSetLightTheme", "WindowSetDarkTheme",
+    "WindowCenter", "WindowFullscreen", "WindowUnfullscreen", "WindowSetSize", "WindowSetMaxSize",
+    "WindowSetMinSize", "WindowSetPosition", "WindowHide", "WindowShow", "WindowMaximise",
+    "WindowToggleMaximise", "WindowUnmaximise", "WindowMinimise", "WindowUnminimise",
+    "WindowSetBackgroundColour"].forEach(name => { window.runtime[name] = noop });
+})();
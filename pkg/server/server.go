@@ -0,0 +1,298 @@
+// Package server runs toolbarr as a web server. The bound App and Starrs methods are
+// available over an authenticated http json api, and the embedded frontend works in a browser.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+)
+
+// runtimeJS replaces the wails runtime in a browser.
+//
+//go:embed runtime.js
+var runtimeJS []byte
+
+// Server timeouts and limits.
+const (
+	readTimeout  = 10 * time.Second
+	pingInterval = 30 * time.Second
+	stopTimeout  = 5 * time.Second
+	maxBody      = 10 * mnd.Megabyte
+)
+
+var (
+	ErrNoPassword = errors.New("a password is required to start the web server")
+	errNoQuestion = errors.New("question is not waiting for an answer")
+	errPanic      = errors.New("panic")
+)
+
+// Config is the input data to start the web server.
+type Config struct {
+	Listen   string // ip:port to listen on.
+	Username string
+	Password string
+	CertFile string // Optional, enables https with KeyFile.
+	KeyFile  string
+	Assets   fs.FS // The built frontend, frontend/dist.
+	Level    logs.Level
+}
+
+// Server holds the running web server data.
+type Server struct {
+	*Config
+	log   *logs.Logger
+	app   *app.App
+	host  *host
+	bound bindings
+}
+
+// New returns a web server for the app. Call Run to start it.
+func New(log *logs.Logger, config *Config, application *app.App) *Server {
+	return &Server{Config: config, log: log, app: application}
+}
+
+// Run starts the app and the web server. Blocks until the context is canceled or the server fails.
+func (s *Server) Run(ctx context.Context) error {
+	if s.Password == "" {
+		return ErrNoPassword
+	}
+
+	handler, err := s.start(ctx)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              s.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: readTimeout,
+		// Event streams end when the context is canceled.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		stop, cancel := context.WithTimeout(context.Background(), stopTimeout)
+		defer cancel()
+
+		_ = server.Shutdown(stop)
+	}()
+
+	s.log.Infof("Web server listening at %s, TLS: %v", s.Listen, s.CertFile != "")
+
+	if s.CertFile != "" {
+		err = server.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("web server: %w", err)
+	}
+
+	return nil
+}
+
+// start starts the app and returns the web server's handler.
+func (s *Server) start(ctx context.Context) (http.Handler, error) {
+	s.host = newHost(ctx, s.log, s.Level)
+	if err := s.app.StartHeadless(ctx, s.host, s.Level); err != nil {
+		return nil, fmt.Errorf("starting app: %w", err)
+	}
+
+	s.bound = bind(s.app, s.app.Starrs)
+
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/call/{name}", s.handleCall)
+	mux.HandleFunc("POST /api/answer/{id}", s.handleAnswer)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /wails/runtime.js", serveJS(runtimeJS))
+	mux.HandleFunc("GET /wails/ipc.js", serveJS(nil)) // runtime.js does it all.
+	mux.HandleFunc("GET /{$}", serveIndex(index))
+	mux.HandleFunc("GET /index.html", serveIndex(index))
+	mux.Handle("GET /", http.FileServerFS(s.Assets))
+
+	return s.auth(mux), nil
+}
+
+// auth requires basic auth on every request. Browsers remember it for the api calls and events.
+// Requests with a body must be json, so other websites cannot post forms here with saved credentials.
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		user, pass, ok := req.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(s.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(s.Password)) != 1 {
+			resp.Header().Set("WWW-Authenticate", `Basic realm="`+mnd.Title+`", charset="UTF-8"`)
+			http.Error(resp, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		if req.Method == http.MethodPost && !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+			http.Error(resp, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		next.ServeHTTP(resp, req)
+	})
+}
+
+// handleCall runs a bound method. The body is a json array of arguments.
+// The reply is {"result": value} or {"error": "message"}.
+func (s *Server) handleCall(resp http.ResponseWriter, req *http.Request) {
+	name := req.PathValue("name")
+
+	body, err := io.ReadAll(http.MaxBytesReader(resp, req.Body, maxBody))
+	if err != nil {
+		s.reply(resp, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Errorf("Panic calling %s: %v", name, r)
+			s.reply(resp, http.StatusInternalServerError, nil, fmt.Errorf("%w: %v", errPanic, r))
+		}
+	}()
+
+	s.log.Debugf("Web call: %s", name)
+
+	result, err := s.bound.call(name, body)
+
+	switch {
+	case errors.Is(err, ErrUnknownMethod):
+		s.reply(resp, http.StatusNotFound, nil, err)
+	case errors.Is(err, ErrDesktopOnly):
+		s.reply(resp, http.StatusNotImplemented, nil, err)
+	case errors.Is(err, ErrArguments):
+		s.reply(resp, http.StatusBadRequest, nil, err)
+	case err != nil:
+		s.reply(resp, http.StatusInternalServerError, nil, err)
+	default:
+		s.reply(resp, http.StatusOK, result, nil)
+	}
+}
+
+func (s *Server) reply(resp http.ResponseWriter, code int, result any, err error) {
+	output := map[string]any{"result": result}
+	if err != nil {
+		output = map[string]any{"error": err.Error()}
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+
+	if err := json.NewEncoder(resp).Encode(output); err != nil {
+		s.log.Errorf("Writing web reply: %v", err)
+	}
+}
+
+// handleAnswer receives the answer to a question sent with an ask event. The body is true or false.
+func (s *Server) handleAnswer(resp http.ResponseWriter, req *http.Request) {
+	var yes bool
+	if err := json.NewDecoder(http.MaxBytesReader(resp, req.Body, mnd.Kilobyte)).Decode(&yes); err != nil {
+		s.reply(resp, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if !s.host.answer(req.PathValue("id"), yes) {
+		s.reply(resp, http.StatusNotFound, nil, errNoQuestion)
+		return
+	}
+
+	s.reply(resp, http.StatusOK, yes, nil)
+}
+
+// handleEvents streams events to a browser until it disconnects.
+func (s *Server) handleEvents(resp http.ResponseWriter, req *http.Request) {
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		http.Error(resp, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := s.host.subscribe()
+	defer unsubscribe()
+
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+			_, _ = io.WriteString(resp, ": ping\n\n")
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.log.Errorf("Encoding event %s: %v", event.Name, err)
+				continue
+			}
+
+			_, _ = fmt.Fprintf(resp, "data: %s\n\n", data)
+		}
+
+		flusher.Flush()
+	}
+}
+
+// index returns the frontend's index.html with the browser runtime added.
+func (s *Server) index() ([]byte, error) {
+	index, err := fs.ReadFile(s.Assets, "index.html")
+	if err != nil {
+		return nil, fmt.Errorf("reading frontend index: %w", err)
+	}
+
+	scripts := []byte(`<script src="/wails/ipc.js"></script><script src="/wails/runtime.js"></script>`)
+	if bytes.Contains(index, []byte("/wails/runtime.js")) {
+		return index, nil
+	}
+
+	if idx := bytes.Index(index, []byte("</head>")); idx >= 0 {
+		return append(index[:idx:idx], append(scripts, index[idx:]...)...), nil
+	}
+
+	return append(scripts, index...), nil
+}
+
+func serveIndex(index []byte) http.HandlerFunc {
+	return func(resp http.ResponseWriter, _ *http.Request) {
+		resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = resp.Write(index)
+	}
+}
+
+func serveJS(script []byte) http.HandlerFunc {
+	return func(resp http.ResponseWriter, _ *http.Request) {
+		resp.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		_, _ = resp.Write(script)
+	}
+}
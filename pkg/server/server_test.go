@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Notifiarr/toolbarr/pkg/app"
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/starrs"
+)
+
+const (
+	testUser = "admin"
+	testPass = "secret"
+)
+
+// newTestServer starts the app with a config file in a temp folder, and serves it with httptest.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	log := logs.New()
+	application := app.New(log, &app.Config{
+		Logger:     log,
+		ConfigFile: filepath.Join(t.TempDir(), "toolbarr.json"),
+		Starrs:     &starrs.Starrs{},
+	})
+	server := New(log, &Config{
+		Username: testUser,
+		Password: testPass,
+		Assets:   fstest.MapFS{"index.html": {Data: []byte("<html><head></head></html>")}},
+	}, application)
+
+	handler, err := server.start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := httptest.NewServer(handler)
+	t.Cleanup(web.Close)
+
+	return web
+}
+
+// call posts a method call with the test credentials and decodes the reply.
+func call(t *testing.T, web *httptest.Server, name, args string) (int, map[string]any) {
+	t.Helper()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		web.URL+"/api/call/"+name, strings.NewReader(args))
+	req.SetBasicAuth(testUser, testPass)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := web.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reply := map[string]any{}
+	_ = json.NewDecoder(resp.Body).Decode(&reply)
+
+	return resp.StatusCode, reply
+}
+
+func TestSaveConfigItemReload(t *testing.T) {
+	t.Parallel()
+
+	web := newTestServer(t)
+
+	// reload is true by default in the frontend. The log setup must not need the wails runtime.
+	code, reply := call(t, web, "app.App.SaveConfigItem", `["Dark", true, true]`)
+	if code != http.StatusOK {
+		t.Fatalf("saving a setting failed: %d, %v", code, reply)
+	}
+
+	code, reply = call(t, web, "app.App.GetConfig", `[]`)
+	if config, _ := reply["result"].(map[string]any); code != http.StatusOK || config["Dark"] != true {
+		t.Errorf("the setting was not saved: %d, %v", code, reply)
+	}
+}
+
+func TestDesktopOnly(t *testing.T) {
+	t.Parallel()
+
+	web := newTestServer(t)
+
+	// Deleting unmapped folders removes files on this computer, so web clients may not.
+	for _, name := range []string{"starrs.Starrs.DeleteUnmapped", "app.App.OpenFolder"} {
+		if code, reply := call(t, web, name, `[]`); code != http.StatusNotImplemented {
+			t.Errorf("%s should be desktop only: %d, %v", name, code, reply)
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	web := newTestServer(t)
+
+	tests := []struct {
+		desc        string
+		user, pass  string
+		method      string
+		contentType string
+		code        int
+	}{
+		{desc: "no credentials", method: http.MethodGet, code: http.StatusUnauthorized},
+		{desc: "wrong password", user: testUser, pass: "wrong", method: http.MethodGet, code: http.StatusUnauthorized},
+		{desc: "wrong user", user: "root", pass: testPass, method: http.MethodGet, code: http.StatusUnauthorized},
+		{desc: "index", user: testUser, pass: testPass, method: http.MethodGet, code: http.StatusOK},
+		{
+			desc: "form post", user: testUser, pass: testPass, method: http.MethodPost,
+			contentType: "application/x-www-form-urlencoded", code: http.StatusUnsupportedMediaType,
+		},
+		{
+			desc: "json post", user: testUser, pass: testPass, method: http.MethodPost,
+			contentType: "application/json", code: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		path := "/"
+		if test.method == http.MethodPost {
+			path = "/api/call/app.App.Version"
+		}
+
+		req, _ := http.NewRequestWithContext(context.Background(), test.method, web.URL+path, strings.NewReader("[]"))
+		if test.user != "" {
+			req.SetBasicAuth(test.user, test.pass)
+		}
+
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+
+		resp, err := web.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.code {
+			t.Errorf("%s: wrong status: %d, expected %d", test.desc, resp.StatusCode, test.code)
+		}
+
+		if test.code == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: browsers need a basic auth challenge", test.desc)
+		}
+
+		if test.desc == "index" && !strings.Contains(string(body), "/wails/runtime.js") {
+			t.Errorf("the index should load the browser runtime: %s", body)
+		}
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	t.Parallel()
+
+	web := newTestServer(t)
+
+	tests := []struct {
+		name string
+		args string
+		code int
+	}{
+		{name: "app.App.Version", args: `[]`, code: http.StatusOK},
+		{name: "app.App.Missing", args: `[]`, code: http.StatusNotFound},
+		{name: "app.App.Quit", args: `[]`, code: http.StatusNotImplemented},
+		{name: "app.App.ExportSettings", args: `[true]`, code: http.StatusNotImplemented},
+		{name: "app.App.Version", args: `[1, 2]`, code: http.StatusBadRequest},
+		{name: "app.App.SaveConfigItem", args: `["NotASetting", 1, false]`, code: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		code, reply := call(t, web, test.name, test.args)
+		if code != test.code {
+			t.Errorf("%s(%s): wrong status: %d, expected %d: %v", test.name, test.args, code, test.code, reply)
+		}
+
+		if _, ok := reply["error"]; ok != (test.code != http.StatusOK) {
+			t.Errorf("%s(%s): errors need an error in the reply: %v", test.name, test.args, reply)
+		}
+	}
+}
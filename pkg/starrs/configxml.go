@@ -0,0 +1,139 @@
+package starrs
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* Reads config.xml files from starr app data folders. */
+
+const configXML = "config.xml"
+
+// appConfigXML is the data we care about in a starr app's config.xml file.
+type appConfigXML struct {
+	XMLName      xml.Name `xml:"Config"`
+	Port         int      `xml:"Port"`
+	SslPort      int      `xml:"SslPort"`
+	EnableSsl    bool     `xml:"EnableSsl"`
+	URLBase      string   `xml:"UrlBase"`
+	APIKey       string   `xml:"ApiKey"`
+	BindAddress  string   `xml:"BindAddress"`
+	AuthMethod   string   `xml:"AuthenticationMethod"`
+	InstanceName string   `xml:"InstanceName"`
+}
+
+// ReadConfigXML parses the config.xml file in a starr app data folder and fills in the
+// URL, API key, auth type and database path on the provided instance config.
+func (s *Starrs) ReadConfigXML(config *AppConfig, folder string) (*AppConfig, error) {
+	s.log.Tracef("Call:ReadConfigXML(%s, %s, %s)", config.App, config.Name, folder)
+
+	if stat, err := os.Stat(folder); err == nil && !stat.IsDir() {
+		folder = filepath.Dir(folder) // they picked a file, probably config.xml.
+	}
+
+	xmlConfig, err := readConfigXML(folder)
+	if err != nil {
+		msg := s.log.Translate("Reading app config: %v", err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	if config.App == "" {
+		config.App = findAppByDB(folder)
+	}
+
+	xmlConfig.fill(config, folder)
+	s.log.Infof("Read %s config file from %s: %s", config.App, folder, config.URL)
+
+	return config, nil
+}
+
+// readConfigXML parses the config.xml file in a starr app data folder.
+func readConfigXML(folder string) (*appConfigXML, error) {
+	data, err := os.ReadFile(filepath.Join(folder, configXML))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", configXML, err)
+	}
+
+	var config appConfigXML
+	if err = xml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", configXML, err)
+	}
+
+	return &config, nil
+}
+
+// fill updates an instance config with the data from a config.xml file.
+func (c *appConfigXML) fill(config *AppConfig, folder string) {
+	config.URL = c.url()
+	config.Key = c.APIKey
+	config.DBPath = dbPath(config.App, folder)
+
+	if config.Name == "" {
+		config.Name = c.InstanceName
+	}
+
+	if config.Name == "" {
+		config.Name = config.App
+	}
+
+	switch strings.ToLower(c.AuthMethod) {
+	case "forms":
+		config.Form = true
+	case "basic":
+		config.Form = false
+	}
+}
+
+// url returns a URL to reach the app from this machine.
+func (c *appConfigXML) url() string {
+	urlBase := strings.Trim(c.URLBase, "/")
+	if urlBase != "" {
+		urlBase += "/"
+	}
+
+	host := c.BindAddress
+	if host == "" || host == "*" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	scheme, port := "http://", c.Port
+	if c.EnableSsl && c.SslPort != 0 {
+		scheme, port = "https://", c.SslPort
+	}
+
+	return scheme + net.JoinHostPort(host, strconv.Itoa(port)) + "/" + urlBase
+}
+
+// dbPath returns the path to the app's database file in a data folder, if it exists.
+func dbPath(app, folder string) string {
+	if app == "" {
+		return ""
+	}
+
+	path := filepath.Join(folder, strings.ToLower(app)+".db")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+
+	return path
+}
+
+// findAppByDB returns the app name for the first starr database file found in a folder.
+// Apps are checked in name order, so a folder with more than one database always returns the same app.
+func findAppByDB(folder string) string {
+	for _, app := range defaultApps() {
+		if dbPath(app, folder) != "" {
+			return app
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,277 @@
+package starrs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* Database health checks and repairs. */
+
+// OrphanCheck finds rows in a table that point to a missing row in a parent table.
+type OrphanCheck struct {
+	Table  string // ie. MovieFiles
+	Column string // ie. MovieId
+	Parent string // ie. Movies
+	Count  int64  // Orphaned rows found.
+}
+
+// ForeignKeyError is a single row from PRAGMA foreign_key_check.
+type ForeignKeyError struct {
+	Table  string
+	RowID  int64
+	Parent string
+}
+
+// DBCheck is the result of a database health check.
+type DBCheck struct {
+	Quick       bool     // quick_check was used instead of integrity_check.
+	Integrity   []string // "ok", or a list of problems.
+	ForeignKeys []*ForeignKeyError
+	Version     int64 // Schema version from the VersionInfo table.
+	Orphans     []*OrphanCheck
+	Healthy     bool
+	Elapsed     string
+}
+
+// DBRepair is the input to repair a database.
+type DBRepair struct {
+	CleanOrphans bool // Delete orphaned rows. A snapshot of the database is saved first.
+	Vacuum       bool // Rebuild the database file to reclaim space.
+}
+
+// DBRepaired is the response to the front end after a repair.
+type DBRepaired struct {
+	Msg      string
+	Snapshot string // Path to the snapshot taken before cleaning orphans.
+	Check    *DBCheck
+}
+
+// orphanChecks returns the orphaned row checks for an app.
+func orphanChecks(app string) []*OrphanCheck {
+	switch app {
+	case "Lidarr":
+		return []*OrphanCheck{{Table: "TrackFiles", Column: "AlbumId", Parent: "Albums"}}
+	case "Radarr":
+		return []*OrphanCheck{{Table: "MovieFiles", Column: "MovieId", Parent: "Movies"}}
+	case "Sonarr", "Whisparr":
+		return []*OrphanCheck{{Table: "EpisodeFiles", Column: "SeriesId", Parent: "Series"}}
+	default:
+		return []*OrphanCheck{}
+	}
+}
+
+// where returns the condition that selects orphaned rows.
+func (o *OrphanCheck) where() string {
+	return fmt.Sprintf("%s NOT IN (SELECT Id FROM %s)", o.Column, o.Parent)
+}
+
+// CheckDB checks a database for corruption, broken foreign keys and orphaned rows. It makes no changes.
+func (s *Starrs) CheckDB(config *AppConfig, quick bool) (*DBCheck, error) {
+	s.log.Tracef("Call:CheckDB(%s, %s, %v)", config.App, config.Name, quick)
+
+	sql, err := s.newSQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	check, err := s.checkDB(sql, config, quick)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Checking Sqlite3 DB: %v", err.Error()))
+	}
+
+	return check, nil
+}
+
+// RepairDB cleans orphaned rows and/or vacuums a database, then checks it again.
+func (s *Starrs) RepairDB(config *AppConfig, input *DBRepair) (*DBRepaired, error) {
+	s.log.Tracef("Call:RepairDB(%s, %s, %v, %v)", config.App, config.Name, input.CleanOrphans, input.Vacuum)
+
+	if !input.CleanOrphans && !input.Vacuum {
+		return nil, errors.New(s.log.Translate("Nothing to repair."))
+	}
+
+	question := s.log.Translate("Really repair %s database?\nPath: %s\nStop %s before continuing.",
+		config.Name, config.DBPath, config.App)
+	if !s.app.Ask(s.log.Translate("Repair Database"), question) {
+		return &DBRepaired{}, nil
+	}
+
+	guard, err := s.guardDB(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, err := s.newSQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	reply := &DBRepaired{}
+	msgs := []string{}
+
+	if input.CleanOrphans {
+		if reply.Snapshot, err = sql.Snapshot(s.ctx); err != nil {
+			return nil, errors.New(s.log.Translate("Saving database snapshot: %v", err.Error()))
+		}
+
+		msgs = append(msgs, s.log.Translate("Saved database snapshot: %s", reply.Snapshot))
+
+		for _, orphan := range orphanChecks(config.App) {
+			rows, err := s.cleanOrphans(sql, orphan)
+			if err != nil {
+				return nil, errors.New(s.log.Translate("Deleting orphaned rows: %v", err.Error()))
+			}
+
+			msgs = append(msgs, s.log.Translate("Deleted %d orphaned rows from table %s.", rows, orphan.Table))
+		}
+	}
+
+	if input.Vacuum {
+		if _, err = sql.conn.ExecContext(s.ctx, "VACUUM"); err != nil {
+			return nil, errors.New(s.log.Translate("Vacuuming database: %v", err.Error()))
+		}
+
+		msgs = append(msgs, s.log.Translate("Vacuumed database."))
+	}
+
+	if reply.Check, err = s.checkDB(sql, config, true); err != nil {
+		return nil, errors.New(s.log.Translate("Checking Sqlite3 DB: %v", err.Error()))
+	}
+
+	sql.Close() // before the app restarts.
+	reply.Msg = s.afterWrite(config, guard, strings.Join(msgs, " "))
+
+	return reply, nil
+}
+
+func (s *Starrs) checkDB(sql *sqlConn, config *AppConfig, quick bool) (*DBCheck, error) {
+	start := time.Now()
+	check := &DBCheck{Quick: quick, ForeignKeys: []*ForeignKeyError{}, Orphans: []*OrphanCheck{}}
+
+	pragma := "PRAGMA integrity_check"
+	if quick {
+		pragma = "PRAGMA quick_check"
+	}
+
+	var err error
+
+	if check.Integrity, err = sql.RowsStringSlice(s.ctx, pragma); err != nil {
+		return nil, err
+	}
+
+	if check.ForeignKeys, err = sql.ForeignKeyCheck(s.ctx); err != nil {
+		return nil, err
+	}
+
+	if check.Version, err = sql.SchemaVersion(s.ctx); err != nil {
+		return nil, err
+	}
+
+	for _, orphan := range orphanChecks(config.App) {
+		if !sql.TableExists(s.ctx, orphan.Table) || !sql.TableExists(s.ctx, orphan.Parent) {
+			continue
+		}
+
+		orphan.Count, err = sql.RowInt64(s.ctx, "SELECT count(1) FROM "+orphan.Table+" WHERE "+orphan.where())
+		if err != nil {
+			return nil, err
+		}
+
+		check.Orphans = append(check.Orphans, orphan)
+	}
+
+	check.Healthy = len(check.Integrity) == 1 && check.Integrity[0] == "ok" && len(check.ForeignKeys) == 0
+
+	for _, orphan := range check.Orphans {
+		check.Healthy = check.Healthy && orphan.Count == 0
+	}
+
+	check.Elapsed = time.Since(start).Round(time.Millisecond).String()
+
+	return check, nil
+}
+
+func (s *Starrs) cleanOrphans(sql *sqlConn, orphan *OrphanCheck) (int64, error) {
+	if !sql.TableExists(s.ctx, orphan.Table) || !sql.TableExists(s.ctx, orphan.Parent) {
+		return 0, nil
+	}
+
+	res, err := sql.Delete(orphan.Table, orphan.where())
+	if err != nil {
+		return 0, err
+	}
+
+	rows, _ := res.RowsAffected()
+
+	return rows, nil
+}
+
+// ForeignKeyCheck returns the rows that point to a missing parent row.
+func (s *sqlConn) ForeignKeyCheck(ctx context.Context) ([]*ForeignKeyError, error) {
+	const query = "PRAGMA foreign_key_check"
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+	defer rows.Close()
+
+	output := []*ForeignKeyError{}
+
+	for rows.Next() {
+		var (
+			fkErr ForeignKeyError
+			rowID sql.NullInt64 // null for WITHOUT ROWID tables.
+			fkID  int64
+		)
+
+		if err := rows.Scan(&fkErr.Table, &rowID, &fkErr.Parent, &fkID); err != nil {
+			return nil, fmt.Errorf("%s: %w", query, err)
+		}
+
+		fkErr.RowID = rowID.Int64
+		output = append(output, &fkErr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+
+	return output, nil
+}
+
+// SchemaVersion returns the newest migration applied to the database. Returns 0 if there are none.
+func (s *sqlConn) SchemaVersion(ctx context.Context) (int64, error) {
+	if !s.TableExists(ctx, "VersionInfo") {
+		return 0, nil
+	}
+
+	return s.RowInt64(ctx, "SELECT ifnull(max(Version), 0) FROM VersionInfo")
+}
+
+// TableExists returns true if the database has a table with the provided name.
+func (s *sqlConn) TableExists(ctx context.Context, table string) bool {
+	count, _ := s.RowInt64(ctx, "SELECT count(1) FROM sqlite_schema WHERE type='table' AND name='"+Escape(table)+"'")
+	return count > 0
+}
+
+// Snapshot saves a consistent copy of the database next to it, and returns the copy's path.
+func (s *sqlConn) Snapshot(ctx context.Context) (string, error) {
+	ext := filepath.Ext(s.config.DBPath)
+	path := strings.TrimSuffix(s.config.DBPath, ext) + ".toolbarr-" + time.Now().Format("20060102-150405") + ext
+	query := "VACUUM INTO '" + Escape(path) + "'"
+	s.log.Debugf("Running Query: %s", query)
+
+	if _, err := s.conn.ExecContext(ctx, query); err != nil {
+		return "", fmt.Errorf("%s: %w", query, err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package starrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+// orphanFixtures add one good and one orphaned file row for each app with orphan checks.
+//
+//nolint:gochecknoglobals,lll
+var orphanFixtures = map[starr.App][]string{
+	starr.Lidarr: {
+		`INSERT INTO Albums (Id, ArtistMetadataId, ForeignAlbumId, Title, Monitored) VALUES (1, 1, 'a', 'Album', 1)`,
+		`INSERT INTO TrackFiles (AlbumId, Quality, Size, Path) VALUES (1, '{}', 1, '/music/a.flac'), (99, '{}', 1, '/music/b.flac')`,
+	},
+	starr.Radarr: {
+		insert("Movies", 1, "/movies/Film"),
+		`INSERT INTO MovieFiles (MovieId, Quality, Size) VALUES (1, '{}', 1), (99, '{}', 1)`,
+	},
+	starr.Sonarr: {
+		insert("Series", 1, "/tv/Show"),
+		`INSERT INTO EpisodeFiles (SeriesId, SeasonNumber, Size, Quality) VALUES (1, 1, 1, '{}'), (99, 1, 1, '{}')`,
+	},
+}
+
+func TestCheckDB(t *testing.T) {
+	t.Parallel()
+
+	for app, queries := range orphanFixtures {
+		test, config := newDB(t, app, queries...)
+
+		for _, quick := range []bool{true, false} {
+			check, err := test.CheckDB(config, quick)
+			if err != nil {
+				t.Fatalf("%s: %v", app, err)
+			}
+
+			if check.Healthy || check.Version != starrtest.SchemaVersion(app) || len(check.Integrity) != 1 ||
+				check.Integrity[0] != "ok" || len(check.Orphans) != 1 || check.Orphans[0].Count != 1 {
+				t.Errorf("%s: wrong check result: %+v", app, check)
+			}
+		}
+	}
+}
+
+func TestCheckDBForeignKeys(t *testing.T) {
+	t.Parallel()
+
+	// Foreign keys are not enforced on insert unless the connection enables them.
+	test, config := newDB(t, starr.Readarr,
+		`CREATE TABLE Editions (Id INTEGER PRIMARY KEY, BookId INTEGER REFERENCES Books(Id))`,
+		`CREATE TABLE Books (Id INTEGER PRIMARY KEY)`,
+		`INSERT INTO Editions (Id, BookId) VALUES (7, 3)`)
+
+	check, err := test.CheckDB(config, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if check.Healthy || len(check.ForeignKeys) != 1 || *check.ForeignKeys[0] != (ForeignKeyError{"Editions", 7, "Books"}) {
+		t.Errorf("wrong foreign key result: %+v", check.ForeignKeys)
+	}
+}
+
+func TestCheckDBCorrupt(t *testing.T) {
+	t.Parallel()
+
+	config := &AppConfig{App: starr.Radarr.String(), DBPath: filepath.Join(t.TempDir(), "corrupt.db")}
+	if err := os.WriteFile(config.DBPath, []byte("this is not a sqlite3 database, but it is long enough"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTester(t).CheckDB(config, false); err == nil {
+		t.Error("a corrupt database should return an error")
+	}
+}
+
+func TestRepairDB(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr, orphanFixtures[starr.Radarr]...)
+
+	if _, err := test.RepairDB(config, &DBRepair{}); err == nil {
+		t.Error("repairing nothing should return an error")
+	}
+
+	test.host.Answer = false
+
+	if reply, err := test.RepairDB(config, &DBRepair{CleanOrphans: true}); err != nil || reply.Check != nil {
+		t.Errorf("declining the repair should do nothing: %v", err)
+	} else if files := test.rows(t, config, "SELECT Id FROM MovieFiles"); len(files) != 2 {
+		t.Errorf("declining the repair should not delete rows: %v", files)
+	}
+
+	test.host.Answer = true
+
+	reply, err := test.RepairDB(config, &DBRepair{CleanOrphans: true, Vacuum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reply.Check.Healthy || len(test.rows(t, config, "SELECT Id FROM MovieFiles")) != 1 {
+		t.Errorf("repair should remove the orphan: %+v", reply.Check)
+	}
+
+	// The snapshot is taken before the orphans are deleted.
+	snapshot := &AppConfig{App: config.App, DBPath: reply.Snapshot}
+	if filepath.Dir(reply.Snapshot) != filepath.Dir(config.DBPath) || len(test.rows(t, snapshot, "SELECT Id FROM MovieFiles")) != 2 {
+		t.Errorf("snapshot %s should contain the orphan", reply.Snapshot)
+	}
+}
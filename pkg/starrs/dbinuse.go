@@ -0,0 +1,240 @@
+package starrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+/* Checks that a starr app is not using its database before we write to it. */
+
+// stopTimeout is how long to wait for an app to stop after asking it to shut down.
+const stopTimeout = 30 * time.Second
+
+// dbGuard is what guardDB did about an app using its database. afterWrite uses it after the write.
+type dbGuard int
+
+const (
+	guardNone    dbGuard = iota // The app was not running.
+	guardRestart                // The app is running, and is restarted after the write so it reads the changes.
+	guardStopped                // The app was stopped, and does not start again on its own.
+)
+
+// DBUsage says if a starr app appears to be using its database, and why.
+type DBUsage struct {
+	WAL        bool   // The write-ahead log file exists.
+	SHM        bool   // The shared memory file exists.
+	Locked     bool   // Another process holds a write lock.
+	Responding bool   // The app answered its API.
+	Version    string // App version, when it answered.
+	InUse      bool
+	Reasons    []string
+}
+
+// CheckDBInUse returns the signs that a starr app is using its database.
+// The instance API is checked too when the instance has a URL.
+func (s *Starrs) CheckDBInUse(config *AppConfig) (*DBUsage, error) {
+	s.log.Tracef("Call:CheckDBInUse(%s, %s)", config.App, config.Name)
+	return s.dbInUse(config)
+}
+
+// ShutdownInstance asks a starr app to stop, and waits for it to stop answering.
+func (s *Starrs) ShutdownInstance(config *AppConfig) (string, error) {
+	s.log.Tracef("Call:ShutdownInstance(%s, %s)", config.App, config.Name)
+
+	if err := s.shutdownInstance(config); err != nil {
+		return "", err
+	}
+
+	return s.log.Translate("Stopped %s.", config.Name), nil
+}
+
+// RestartInstance asks a running starr app to restart. It reads its database again when it starts.
+func (s *Starrs) RestartInstance(config *AppConfig) (string, error) {
+	s.log.Tracef("Call:RestartInstance(%s, %s)", config.App, config.Name)
+
+	if err := s.restartInstance(config); err != nil {
+		return "", err
+	}
+
+	return s.log.Translate("Restarting %s.", config.Name), nil
+}
+
+func (s *Starrs) dbInUse(config *AppConfig) (*DBUsage, error) {
+	usage := &DBUsage{Reasons: []string{}}
+
+	// Check the files before opening the database; our own connection creates them in WAL mode.
+	if _, err := os.Stat(config.DBPath + "-wal"); err == nil {
+		usage.WAL = true
+		usage.Reasons = append(usage.Reasons, s.log.Translate("The write-ahead log file exists: %s-wal", config.DBPath))
+	}
+
+	if _, err := os.Stat(config.DBPath + "-shm"); err == nil {
+		usage.SHM = true
+		usage.Reasons = append(usage.Reasons, s.log.Translate("The shared memory file exists: %s-shm", config.DBPath))
+	}
+
+	sql, err := s.newSQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	if usage.Locked, err = sql.Locked(s.ctx); err != nil {
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	} else if usage.Locked {
+		usage.Reasons = append(usage.Reasons, s.log.Translate("Another process is writing to the database."))
+	}
+
+	if config.URL != "" {
+		if test, err := s.testInstance(config); err == nil {
+			usage.Responding, usage.Version = true, test.Version
+			usage.Reasons = append(usage.Reasons,
+				s.log.Translate("%s is running at %s, version %s.", config.App, config.URL, test.Version))
+		}
+	}
+
+	usage.InUse = usage.WAL || usage.SHM || usage.Locked || usage.Responding
+
+	return usage, nil
+}
+
+// guardDB is called before writing to a database. If the app appears to be using it,
+// the user may stop the app with its API, write anyway, or cancel with an error.
+// Pass the returned value to afterWrite once the database is closed.
+func (s *Starrs) guardDB(config *AppConfig) (dbGuard, error) {
+	usage, err := s.dbInUse(config)
+	if err != nil {
+		return guardNone, err
+	} else if !usage.InUse {
+		return guardNone, nil
+	}
+
+	reasons := strings.Join(usage.Reasons, "\n")
+
+	if usage.Responding && s.askStop(config, reasons) {
+		return guardStopped, s.shutdownInstance(config)
+	}
+
+	question := s.log.Translate("%s appears to be using its database:\n%s\n\n"+
+		"%s may overwrite the changes from its cache, or the database may be corrupted. Write anyway?",
+		config.Name, reasons, config.App)
+	if usage.Responding {
+		question += " " + s.log.Translate("%s will be restarted after the write.", config.App)
+	}
+
+	if !s.app.Ask(s.log.Translate("Database In Use"), question) {
+		return guardNone, errors.New(s.log.Translate("Canceled: %s database is in use. Stop %s and try again.",
+			config.Name, config.App))
+	}
+
+	if usage.Responding {
+		return guardRestart, nil
+	}
+
+	return guardNone, nil
+}
+
+// askStop asks to stop an app before writing to its database. Hosts without a person to ask,
+// like the cli, only stop apps when that was allowed up front, because nothing starts them again.
+func (s *Starrs) askStop(config *AppConfig, reasons string) bool {
+	if stopper, ok := s.app.(mnd.Stopper); ok {
+		if stopper.StopApps() {
+			s.log.Warnf("Stopping %s before writing to its database. It will not start again on its own.", config.Name)
+		}
+
+		return stopper.StopApps()
+	}
+
+	question := s.log.Translate("%s appears to be using its database:\n%s\n\n"+
+		"Stop %s with its API before writing to the database? It will not start again on its own.",
+		config.Name, reasons, config.App)
+
+	return s.app.Ask(s.log.Translate("Stop %s", config.App), question)
+}
+
+// afterWrite restarts the app if guardDB said to, and adds the result to the message.
+// Close the database first; the app must not start while it's open here.
+// An app stopped with its API cannot be started with it, so the message says it's still stopped.
+func (s *Starrs) afterWrite(config *AppConfig, guard dbGuard, msg string) string {
+	switch guard {
+	case guardStopped:
+		return msg + " " + s.log.Translate("%s was stopped, and does not start again on its own. "+
+			"Start it to use the changes.", config.Name)
+	case guardRestart:
+		if err := s.restartInstance(config); err != nil {
+			return msg + " " + s.log.Translate("Restarting %s failed: %v", config.Name, err.Error())
+		}
+
+		return msg + " " + s.log.Translate("Restarting %s.", config.Name)
+	case guardNone:
+	}
+
+	return msg
+}
+
+func (s *Starrs) shutdownInstance(config *AppConfig) error {
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return err
+	}
+
+	if err = instance.postInto(s.ctx, "system/shutdown", nil, &map[string]any{}); err != nil {
+		return errors.New(s.log.Translate("Stopping %s: %v", config.Name, err.Error()))
+	}
+
+	for start := time.Now(); time.Since(start) < stopTimeout; time.Sleep(waitTime) {
+		if _, err := s.testInstance(config); err != nil {
+			return nil
+		}
+	}
+
+	return errors.New(s.log.Translate("%s did not stop after %v.", config.Name, stopTimeout))
+}
+
+func (s *Starrs) restartInstance(config *AppConfig) error {
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return err
+	}
+
+	if err = instance.postInto(s.ctx, "system/restart", nil, &map[string]any{}); err != nil {
+		return errors.New(s.log.Translate("Restarting %s: %v", config.Name, err.Error()))
+	}
+
+	return nil
+}
+
+// Locked returns true if another connection holds a write lock on the database.
+func (s *sqlConn) Locked(ctx context.Context) (bool, error) {
+	conn, err := s.conn.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		var sqlErr *sqlite.Error
+		if errors.As(err, &sqlErr) {
+			// The low byte is the primary result code.
+			if code := sqlErr.Code() & 0xff; code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED {
+				return true, nil
+			}
+		}
+
+		return false, fmt.Errorf("BEGIN IMMEDIATE: %w", err)
+	}
+
+	if _, err = conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		return false, fmt.Errorf("ROLLBACK: %w", err)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,173 @@
+package starrs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestCheckDBInUse(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr)
+
+	if usage, err := test.CheckDBInUse(config); err != nil || usage.InUse {
+		t.Fatalf("a closed database should not be in use: %+v, %v", usage, err)
+	}
+
+	for _, ext := range []string{"-wal", "-shm"} {
+		if err := os.WriteFile(config.DBPath+ext, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := test.CheckDBInUse(config)
+	if err != nil || !usage.InUse || !usage.WAL || !usage.SHM || len(usage.Reasons) != 2 {
+		t.Errorf("wal and shm files should be found: %+v, %v", usage, err)
+	}
+
+	_ = os.Remove(config.DBPath + "-wal")
+	_ = os.Remove(config.DBPath + "-shm")
+
+	// Hold a write lock like a running app in the middle of a write.
+	sql, err := test.newSQL(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sql.Close()
+
+	if _, err = sql.conn.Exec("BEGIN IMMEDIATE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if usage, err := test.CheckDBInUse(config); err != nil || !usage.InUse || !usage.Locked {
+		t.Errorf("the write lock should be found: %+v, %v", usage, err)
+	}
+}
+
+func TestGuardDB(t *testing.T) {
+	t.Parallel()
+
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	test, config := newDB(t, starr.Radarr, rootFolder("/movies/"), insert("Movies", 1, "/movies/Film"))
+	config.URL, config.Key = server.URL(), starrtest.APIKey
+
+	if usage, err := test.CheckDBInUse(config); err != nil || !usage.Responding || usage.Version != starrtest.Version {
+		t.Fatalf("the running app should be found: %+v, %v", usage, err)
+	}
+
+	test.host.Answer = false
+
+	if _, err := test.UpdateRootFolder(config, "/movies/", "/films/"); err == nil {
+		t.Error("declining to stop the app or write anyway should return an error")
+	} else if paths := test.rows(t, config, "SELECT Path FROM Movies"); paths[0] != "/movies/Film" {
+		t.Errorf("the database should not change: %v", paths)
+	}
+
+	if server.Called("POST /api/v3/system/shutdown") {
+		t.Error("the app should not be stopped without asking")
+	}
+
+	// A write anyway restarts the app, so it reads the changes.
+	if msg := test.afterWrite(config, guardRestart, "ok"); !strings.HasPrefix(msg, "ok ") ||
+		!server.Called("POST /api/v3/system/restart") {
+		t.Errorf("the app should be restarted: %s", msg)
+	}
+
+	// Answering yes to every question writes anyway; nothing starts a stopped app from a cli.
+	test.host.Answer = true
+
+	if _, err := test.UpdateRootFolder(config, "/movies/", "/films/"); err != nil {
+		t.Fatal(err)
+	} else if server.Called("POST /api/v3/system/shutdown") {
+		t.Error("the app should only be stopped when that is allowed up front")
+	}
+
+	if paths := test.rows(t, config, "SELECT Path FROM Movies"); paths[0] != "/films/Film" {
+		t.Errorf("the database should change after writing anyway: %v", paths)
+	}
+
+	test.host.Stop = true
+
+	if reply, err := test.UpdateRootFolder(config, "/films/", "/movies/"); err != nil {
+		t.Fatal(err)
+	} else if !server.Called("POST /api/v3/system/shutdown") {
+		t.Error("the app should be stopped before the write")
+	} else if !strings.Contains(reply.Msg, "does not start again") {
+		t.Errorf("the message should say the app is still stopped: %s", reply.Msg)
+	}
+
+	if paths := test.rows(t, config, "SELECT Path FROM Movies"); paths[0] != "/movies/Film" {
+		t.Errorf("the database should change after the app stops: %v", paths)
+	}
+}
+
+// TestRestartAfterClose checks the database is closed before the app is asked to restart.
+func TestRestartAfterClose(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are counted with /proc")
+	}
+
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	test, config := newDB(t, starr.Radarr, rootFolder("/movies/"), insert("Movies", 1, "/movies/Film"))
+	handler := server.Config.Handler
+
+	var openAtRestart atomic.Int32
+
+	openAtRestart.Store(-1)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/system/restart") {
+			openAtRestart.Store(openFiles(config.DBPath))
+		}
+
+		handler.ServeHTTP(resp, req)
+	}))
+	defer proxy.Close()
+
+	config.URL, config.Key = proxy.URL+"/", starrtest.APIKey
+	test.host.Answer = true
+
+	reply, err := test.UpdateRootFolder(config, "/movies/", "/films/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if open := openAtRestart.Load(); open != 0 {
+		t.Errorf("the database should be closed before the app restarts; open files: %d", open)
+	}
+
+	if !strings.Contains(reply.Msg, "Restarting") || len(reply.Info.RootFolders) != 1 ||
+		reply.Info.RootFolders[0] != "/films/" {
+		t.Errorf("the reply should be read before the restart: %+v", reply)
+	}
+}
+
+// openFiles counts the files this process has open for a database, including its wal and shm files.
+func openFiles(dbPath string) int32 {
+	links, _ := filepath.Glob("/proc/self/fd/*")
+
+	var count int32
+
+	for _, link := range links {
+		if target, err := os.Readlink(link); err == nil && strings.HasPrefix(target, dbPath) {
+			count++
+		}
+	}
+
+	return count
+}
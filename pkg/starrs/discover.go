@@ -0,0 +1,340 @@
+package starrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+)
+
+/* Discovers starr apps on the local machine and network. */
+
+const (
+	// discoverWorkers is the maximum number of host:port combinations probed at the same time.
+	discoverWorkers = 32
+	// discoverMaxHosts keeps a user from scanning a /8 by accident.
+	discoverMaxHosts = 1024
+	// discoverTimeout is used when no timeout is provided.
+	discoverTimeout = 2 * time.Second
+)
+
+var ErrTooManyHosts = errors.New("too many hosts to scan")
+
+// DefaultPorts is the default port for each starr app.
+//
+//nolint:gochecknoglobals,gomnd
+var DefaultPorts = map[int]string{
+	7878: starr.Radarr.String(),
+	8989: starr.Sonarr.String(),
+	8686: starr.Lidarr.String(),
+	8787: starr.Readarr.String(),
+	9696: starr.Prowlarr.String(),
+	6969: starr.Whisparr.String(),
+}
+
+// defaultApps returns the app names in DefaultPorts, sorted, so lookups by app are repeatable.
+func defaultApps() []string {
+	apps := make([]string, 0, len(DefaultPorts))
+	for _, app := range DefaultPorts {
+		apps = append(apps, app)
+	}
+
+	sort.Strings(apps)
+
+	return apps
+}
+
+// DiscoverInput is the data the frontend provides to find starr apps.
+type DiscoverInput struct {
+	Hosts   []string      // Host names, IPs or CIDR subnets to probe.
+	Ports   []int         // Ports to probe on each host. Uses DefaultPorts if empty.
+	Local   bool          // Look for config.xml files on this machine?
+	Timeout time.Duration // How long to wait for each probe.
+}
+
+// Discovered is a starr app found during discovery.
+// The user must confirm these before they are saved as instances.
+type Discovered struct {
+	Config       AppConfig
+	Version      string
+	Source       string // "local" or "network"
+	Folder       string // app data folder, only for local results.
+	AuthRequired bool   // app found, but we need a username and password (or api key) to use it.
+}
+
+// Discover finds starr apps on this machine and on the provided hosts and subnets.
+func (s *Starrs) Discover(input *DiscoverInput) ([]*Discovered, error) {
+	s.log.Tracef("Call:Discover(%v, %v, %v)", input.Hosts, input.Ports, input.Local)
+
+	if input.Timeout <= 0 {
+		input.Timeout = discoverTimeout
+	}
+
+	if len(input.Ports) == 0 {
+		for port := range DefaultPorts {
+			input.Ports = append(input.Ports, port)
+		}
+	}
+
+	hosts, err := expandHosts(input.Hosts)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Invalid host list: %v", err.Error()))
+	}
+
+	local := []*Discovered{}
+	if input.Local {
+		local = s.discoverLocal()
+	}
+
+	found := mergeDiscovered(local, s.discoverNetwork(hosts, input.Ports, input.Timeout))
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Config.App == found[j].Config.App {
+			return found[i].Config.URL < found[j].Config.URL
+		}
+
+		return found[i].Config.App < found[j].Config.App
+	})
+
+	s.log.Infof("Discovered %d starr apps.", len(found))
+
+	return found, nil
+}
+
+// mergeDiscovered adds the network results to the local results, without apps that were found both ways.
+// The addresses differ, ie. localhost and a LAN IP, so apps match on the port and the api key. Network
+// results without an api key need a password, and match a local result on the port and the instance name.
+func mergeDiscovered(local, network []*Discovered) []*Discovered {
+	found := append([]*Discovered{}, local...)
+	seen := make(map[string]bool)
+
+	for _, item := range local {
+		seen[discoveredKey(item, true)] = true
+		seen[discoveredKey(item, false)] = true
+	}
+
+	for _, item := range network {
+		if !seen[discoveredKey(item, item.Config.Key != "")] {
+			found = append(found, item)
+		}
+	}
+
+	return found
+}
+
+// discoveredKey identifies a discovered app by its app name, port, and api key or instance name.
+func discoveredKey(item *Discovered, byKey bool) string {
+	port := ""
+	if u, err := url.Parse(item.Config.URL); err == nil {
+		port = u.Port()
+	}
+
+	if byKey {
+		return item.Config.App + "\x00" + port + "\x00key:" + item.Config.Key
+	}
+
+	return item.Config.App + "\x00" + port + "\x00name:" + item.Config.Name
+}
+
+// discoverLocal looks for config.xml files in the default app data folders.
+func (s *Starrs) discoverLocal() []*Discovered {
+	found := []*Discovered{}
+
+	for _, app := range defaultApps() {
+		for _, folder := range appDataFolders(app) {
+			config, err := readConfigXML(folder)
+			if err != nil {
+				continue
+			}
+
+			s.log.Debugf("Found %s config file in %s", app, folder)
+
+			item := &Discovered{Config: AppConfig{App: app}, Source: "local", Folder: folder}
+			config.fill(&item.Config, folder)
+			found = append(found, item)
+		}
+	}
+
+	return found
+}
+
+// appDataFolders returns the folders a starr app may keep its config.xml in.
+func appDataFolders(app string) []string {
+	home, _ := os.UserHomeDir()
+
+	switch {
+	case mnd.IsWindows:
+		return []string{filepath.Join(os.Getenv("ProgramData"), app)}
+	case mnd.IsMac:
+		return []string{filepath.Join(home, ".config", app)}
+	default:
+		return []string{
+			filepath.Join(home, ".config", app),
+			filepath.Join("/var/lib", strings.ToLower(app)),
+			filepath.Join("/var/lib", app),
+		}
+	}
+}
+
+// discoverNetwork probes every port on every host using a bounded worker pool.
+func (s *Starrs) discoverNetwork(hosts []string, ports []int, timeout time.Duration) []*Discovered {
+	input := make(chan string)
+	output := make(chan *Discovered)
+	wait := sync.WaitGroup{}
+
+	for range discoverWorkers {
+		wait.Add(1)
+
+		go func() {
+			defer s.log.CapturePanic()
+			defer wait.Done()
+
+			for hostPort := range input {
+				if item := s.probe(hostPort, timeout); item != nil {
+					output <- item
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, host := range hosts {
+			for _, port := range ports {
+				input <- net.JoinHostPort(host, strconv.Itoa(port))
+			}
+		}
+
+		close(input)
+		wait.Wait()
+		close(output)
+	}()
+
+	found := []*Discovered{}
+	for item := range output {
+		found = append(found, item)
+	}
+
+	return found
+}
+
+// probe fetches initialize.js from a host:port, the same way testWithoutKey works.
+// Plain http is tried first. If something answers that does not speak plain http, https is tried.
+// Returns nil if nothing that looks like a starr app is found.
+// The probe timeout is short, so it is not saved with the result; instances get the default timeout.
+func (s *Starrs) probe(hostPort string, timeout time.Duration) *Discovered {
+	item, err := s.probeURL("http://"+hostPort+"/", hostPort, timeout)
+	if item != nil || !isListening(err) {
+		return item
+	}
+
+	item, _ = s.probeURL("https://"+hostPort+"/", hostPort, timeout)
+
+	return item
+}
+
+// probeURL fetches initialize.js from a url. Returns the error if nothing that looks like a starr app is found.
+func (s *Starrs) probeURL(address, hostPort string, timeout time.Duration) (*Discovered, error) {
+	config := &AppConfig{URL: address}
+	instance := s.newInstance(&AppConfig{URL: config.URL, Timeout: timeout})
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	ijs, err := instance.GetInitializeJS(ctx)
+	if err != nil && !isUnauthorized(err) {
+		return nil, err
+	} else if err != nil || ijs.App == "" {
+		// Something is listening and wants a password (or redirected us to a login page).
+		// Guess the app from the port.
+		_, port, _ := net.SplitHostPort(hostPort)
+		portNum, _ := strconv.Atoi(port)
+
+		if config.App = DefaultPorts[portNum]; config.App == "" {
+			return nil, nil //nolint:nilnil // nothing found, and nothing to retry.
+		}
+
+		config.Name = config.App
+
+		return &Discovered{Config: *config, Source: "network", AuthRequired: true}, nil
+	}
+
+	config.App = ijs.App
+	config.Name = ijs.InstanceName
+	config.Key = ijs.APIKey
+
+	if config.Name == "" {
+		config.Name = ijs.App
+	}
+
+	if ijs.URLBase != "" {
+		config.URL += strings.Trim(ijs.URLBase, "/") + "/"
+	}
+
+	return &Discovered{
+		Config:       *config,
+		Version:      ijs.Version,
+		Source:       "network",
+		AuthRequired: ijs.APIKey == "",
+	}, nil
+}
+
+// isListening returns true if a probe error came from something listening on the port.
+// Timeouts and refused connections mean there is nothing to try https on.
+func isListening(err error) bool {
+	var netErr net.Error
+
+	return err != nil && !errors.Is(err, syscall.ECONNREFUSED) &&
+		!errors.Is(err, context.DeadlineExceeded) && !(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// isUnauthorized returns true if the error is a 401 from a starr app.
+func isUnauthorized(err error) bool {
+	reqErr := &starr.ReqError{}
+	return errors.As(err, &reqErr) && reqErr.Code == http.StatusUnauthorized
+}
+
+// expandHosts turns CIDR subnets into a list of addresses. Other entries are passed through.
+func expandHosts(input []string) ([]string, error) {
+	hosts := []string{}
+
+	for _, entry := range input {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			hosts = append(hosts, entry)
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry, err)
+		}
+
+		for addr := prefix.Masked().Addr(); prefix.Contains(addr); addr = addr.Next() {
+			if len(hosts) >= discoverMaxHosts {
+				return nil, fmt.Errorf("%w: limit is %d", ErrTooManyHosts, discoverMaxHosts)
+			}
+
+			hosts = append(hosts, addr.String())
+		}
+	}
+
+	return hosts, nil
+}
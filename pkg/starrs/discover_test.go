@@ -0,0 +1,83 @@
+package starrs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestMergeDiscovered(t *testing.T) {
+	t.Parallel()
+
+	local := []*Discovered{
+		{Config: AppConfig{App: "Radarr", Name: "Radarr", URL: "http://localhost:7878/", Key: "abc"}, Source: "local"},
+		{Config: AppConfig{App: "Sonarr", Name: "Sonarr", URL: "http://localhost:8989/tv/", Key: "def"}, Source: "local"},
+	}
+
+	tests := []struct {
+		desc string
+		item AppConfig
+		new  bool
+	}{
+		{desc: "same app on the lan ip", item: AppConfig{App: "Radarr", URL: "http://10.1.1.2:7878/", Key: "abc"}},
+		{desc: "same app with a password", item: AppConfig{App: "Sonarr", Name: "Sonarr", URL: "http://10.1.1.2:8989/"}},
+		{desc: "another radarr", item: AppConfig{App: "Radarr", URL: "http://10.1.1.3:7878/", Key: "xyz"}, new: true},
+		{desc: "another port", item: AppConfig{App: "Radarr", URL: "http://10.1.1.2:7879/", Key: "abc"}, new: true},
+		{
+			desc: "another sonarr with a password", new: true,
+			item: AppConfig{App: "Sonarr", Name: "Sonarr", URL: "http://10.1.1.3:8990/"},
+		},
+	}
+
+	for _, test := range tests {
+		found := mergeDiscovered(local, []*Discovered{{Config: test.item, Source: "network"}})
+		if added := len(found) == len(local)+1; added != test.new {
+			t.Errorf("%s: added %v, expected %v", test.desc, added, test.new)
+		}
+	}
+}
+
+func TestProbeHTTPS(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.NewTLS(starr.Sonarr)
+	defer server.Close()
+
+	hostPort := strings.TrimPrefix(server.Server.URL, "https://")
+
+	item := test.probe(hostPort, time.Second)
+	if item == nil || item.Config.App != starr.Sonarr.String() || item.Config.URL != "https://"+hostPort+"/" {
+		t.Fatalf("an https-only app should be found with an https url: %+v", item)
+	}
+
+	server.Close()
+
+	if item := test.probe(hostPort, time.Second); item != nil {
+		t.Errorf("nothing should be found on a closed port: %+v", item)
+	}
+}
+
+func TestFindAppByDB(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"sonarr.db", "lidarr.db", "radarr.db"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mnd.Mode0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 10 {
+		if app := findAppByDB(dir); app != starr.Lidarr.String() {
+			t.Fatalf("a folder with more than one database should always return the first app by name: %s", app)
+		}
+	}
+}
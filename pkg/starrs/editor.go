@@ -0,0 +1,240 @@
+package starrs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+)
+
+/* List and bulk edit library items with the app's editor API, a safe alternative to the migrator. */
+
+// LibraryFilter picks library items. Empty fields match every item.
+type LibraryFilter struct {
+	RootFolder string  // Items inside this root folder.
+	ProfileID  int64   // Items with this quality profile.
+	Monitored  *bool   // Only monitored, or only unmonitored, items.
+	Tags       []int64 // Items with any of these tags.
+	PathRegex  string  // Items with a path that matches this regular expression.
+}
+
+// LibraryEdit is a bulk edit for library items. Empty fields are not changed.
+type LibraryEdit struct {
+	IDs               []int64
+	Monitored         *bool
+	QualityProfileID  int64
+	MetadataProfileID int64 // Lidarr and Readarr only.
+	Tags              []int64
+	ApplyTags         string // add, remove or replace. Defaults to add.
+	RootFolderPath    string
+	MoveFiles         bool   // Move the item folders into the new root folder.
+	SeriesType        string // Sonarr and Whisparr only: standard, daily or anime.
+}
+
+// LibraryReply is the response to the frontend after a bulk edit.
+type LibraryReply struct {
+	Msg   string
+	Items []LibraryItem
+}
+
+// Library returns the library items that match a filter.
+func (s *Starrs) Library(config *AppConfig, filter *LibraryFilter) ([]LibraryItem, error) {
+	s.log.Tracef("Call:Library(%s, %s, %+v)", config.App, config.Name, filter)
+
+	items, err := s.library(config, filter)
+	if err != nil {
+		msg := s.log.Translate("Getting library: %v", err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return items, nil
+}
+
+func (s *Starrs) library(config *AppConfig, filter *LibraryFilter) ([]LibraryItem, error) {
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := instance.library()
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(items, func(item LibraryItem) bool { return !match(item) }), nil
+}
+
+// matcher returns a function that reports if an item matches the filter.
+func (f *LibraryFilter) matcher() (func(LibraryItem) bool, error) {
+	if f == nil {
+		return func(LibraryItem) bool { return true }, nil
+	}
+
+	var re *regexp.Regexp
+
+	if f.PathRegex != "" {
+		var err error
+		if re, err = regexp.Compile(f.PathRegex); err != nil {
+			return nil, fmt.Errorf("invalid path regex: %w", err)
+		}
+	}
+
+	return func(item LibraryItem) bool {
+		if _, ok := itemInRoot(item.Path(), []string{f.RootFolder}); f.RootFolder != "" && !ok {
+			return false
+		}
+
+		if monitored, _ := item["monitored"].(bool); f.Monitored != nil && monitored != *f.Monitored {
+			return false
+		}
+
+		if f.ProfileID != 0 && item.Int("qualityProfileId") != f.ProfileID {
+			return false
+		}
+
+		hasTag := func(tag int64) bool { return slices.Contains(f.Tags, tag) }
+		if len(f.Tags) > 0 && !slices.ContainsFunc(item.Tags(), hasTag) {
+			return false
+		}
+
+		return re == nil || re.MatchString(item.Path())
+	}, nil
+}
+
+// EditLibrary changes many library items at once with the app's editor API.
+// Moving files to a new root folder asks for confirmation first.
+func (s *Starrs) EditLibrary(config *AppConfig, edit *LibraryEdit) (*LibraryReply, error) {
+	s.log.Tracef("Call:EditLibrary(%s, %s, %d items)", config.App, config.Name, len(edit.IDs))
+
+	lib := getLibrary(config.App)
+	if lib == nil {
+		return nil, errors.New(s.log.Translate("%s has no library to edit.", config.App))
+	}
+
+	body, err := s.editorBody(config, lib, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.MoveFiles && edit.RootFolderPath != "" {
+		question := s.log.Translate("Move the folders for %d items to %s? Files are moved by %s.",
+			len(edit.IDs), edit.RootFolderPath, config.Name)
+		if !s.app.Ask(s.log.Translate("Move Files"), question) {
+			return &LibraryReply{Items: []LibraryItem{}}, nil
+		}
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().Add(waitTime)
+	// Svelte just won't update some reactive variables if you return quickly.
+	defer func() { time.Sleep(time.Until(end)) }()
+
+	items, err := instance.editItems(body)
+	if err != nil {
+		msg := s.log.Translate("Editing %s library: %v", config.Name, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &LibraryReply{
+		Msg:   s.log.Translate("Updated %d %s items.", len(items), config.Name),
+		Items: items,
+	}, nil
+}
+
+// editorBody validates a bulk edit and returns the editor request body for an app.
+func (s *Starrs) editorBody(config *AppConfig, lib *library, edit *LibraryEdit) (map[string]any, error) {
+	if len(edit.IDs) == 0 {
+		return nil, errors.New(s.log.Translate("Select items to edit."))
+	}
+
+	body := map[string]any{lib.IDs: edit.IDs, "moveFiles": edit.MoveFiles}
+
+	if edit.Monitored != nil {
+		body["monitored"] = *edit.Monitored
+	}
+
+	if edit.QualityProfileID != 0 {
+		body["qualityProfileId"] = edit.QualityProfileID
+	}
+
+	if edit.MetadataProfileID != 0 {
+		if _, ok := lib.Profiles["metadataProfileId"]; !ok {
+			return nil, errors.New(s.log.Translate("%s has no metadata profiles.", config.App))
+		}
+
+		body["metadataProfileId"] = edit.MetadataProfileID
+	}
+
+	if edit.SeriesType != "" {
+		if lib.Path != "series" {
+			return nil, errors.New(s.log.Translate("%s has no series type.", config.App))
+		} else if !slices.Contains([]string{"standard", "daily", "anime"}, edit.SeriesType) {
+			return nil, errors.New(s.log.Translate("Invalid series type: %s", edit.SeriesType))
+		}
+
+		body["seriesType"] = edit.SeriesType
+	}
+
+	if edit.RootFolderPath != "" {
+		body["rootFolderPath"] = edit.RootFolderPath
+	}
+
+	if edit.Tags != nil {
+		apply := edit.ApplyTags
+		if apply == "" {
+			apply = "add"
+		} else if !slices.Contains([]string{"add", "remove", "replace"}, apply) {
+			return nil, errors.New(s.log.Translate("Invalid tag action: %s", apply))
+		}
+
+		body["tags"], body["applyTags"] = edit.Tags, apply
+	}
+
+	if len(body) == 2 { //nolint:gomnd // Only the ids and moveFiles.
+		return nil, errors.New(s.log.Translate("Nothing to change."))
+	}
+
+	return body, nil
+}
+
+// editItems sends a body from editorBody to the app's editor, and returns the changed items.
+func (i *instance) editItems(body map[string]any) ([]LibraryItem, error) {
+	if starr.App(i.config.App) != starr.Radarr {
+		// The starr library only has an editor for movies.
+		items := []LibraryItem{}
+		if err := i.putInto(i.ctx, getLibrary(i.config.App).Path+"/editor", body, &items); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+
+	edit := &radarr.BulkEdit{}
+	if err := convert(body, edit); err != nil {
+		return nil, err
+	}
+
+	movies, err := radarr.New(i.Config).EditMoviesContext(i.ctx, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	return toItems(movies)
+}
@@ -0,0 +1,154 @@
+package starrs
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func newLibrary(t *testing.T) (*tester, *starrtest.Server, *AppConfig) {
+	t.Helper()
+
+	server := starrtest.New(starr.Sonarr)
+	t.Cleanup(server.Close)
+
+	server.Seed("series",
+		starrtest.Item{"id": 1, "title": "A", "path": "/tv/A", "monitored": true, "qualityProfileId": 1, "tags": []int64{1}},
+		starrtest.Item{"id": 2, "title": "B", "path": "/tv/B", "monitored": false, "qualityProfileId": 2, "tags": []int64{}},
+		starrtest.Item{"id": 3, "title": "C", "path": "/anime/C", "monitored": true, "qualityProfileId": 1,
+			"tags": []int64{1, 2}},
+	)
+
+	return newTester(t), server, newConfig(server)
+}
+
+func TestLibrary(t *testing.T) {
+	t.Parallel()
+
+	test, _, config := newLibrary(t)
+	yes, no := true, false
+
+	tests := map[string]struct {
+		filter *LibraryFilter
+		want   []int64
+	}{
+		"none":      {filter: nil, want: []int64{1, 2, 3}},
+		"root":      {filter: &LibraryFilter{RootFolder: "/tv"}, want: []int64{1, 2}},
+		"profile":   {filter: &LibraryFilter{ProfileID: 1}, want: []int64{1, 3}},
+		"monitored": {filter: &LibraryFilter{Monitored: &yes}, want: []int64{1, 3}},
+		"unmonitor": {filter: &LibraryFilter{Monitored: &no}, want: []int64{2}},
+		"tags":      {filter: &LibraryFilter{Tags: []int64{2, 5}}, want: []int64{3}},
+		"regex":     {filter: &LibraryFilter{PathRegex: "/[BC]$"}, want: []int64{2, 3}},
+		"combined":  {filter: &LibraryFilter{RootFolder: "/tv/", Tags: []int64{1}}, want: []int64{1}},
+	}
+
+	for name, check := range tests {
+		items, err := test.Library(config, check.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		ids := []int64{}
+		for _, item := range items {
+			ids = append(ids, item.ID())
+		}
+
+		if len(ids) != len(check.want) {
+			t.Errorf("%s: wanted %v, got: %v", name, check.want, ids)
+			continue
+		}
+
+		for idx := range ids {
+			if ids[idx] != check.want[idx] {
+				t.Errorf("%s: wanted %v, got: %v", name, check.want, ids)
+			}
+		}
+	}
+
+	if _, err := test.Library(config, &LibraryFilter{PathRegex: "("}); err == nil {
+		t.Error("an invalid regex should return an error")
+	}
+}
+
+func TestEditLibrary(t *testing.T) {
+	t.Parallel()
+
+	test, server, config := newLibrary(t)
+	no := false
+
+	reply, err := test.EditLibrary(config, &LibraryEdit{
+		IDs: []int64{1, 3}, Monitored: &no, QualityProfileID: 5, SeriesType: "anime",
+		Tags: []int64{1}, ApplyTags: "remove", RootFolderPath: "/new/", MoveFiles: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reply.Items) != 2 {
+		t.Fatalf("two items should be updated: %v", reply.Items)
+	}
+
+	items := server.Items("series")
+	for _, idx := range []int{0, 2} {
+		item := LibraryItem(items[idx])
+		if item["monitored"] != false || item.Int("qualityProfileId") != 5 || item["seriesType"] != "anime" {
+			t.Errorf("item %d was not edited: %v", item.ID(), item)
+		}
+
+		if item.Path() != "/new/"+item.String("title") {
+			t.Errorf("item %d should move to the new root folder: %s", item.ID(), item.Path())
+		}
+
+		if tags := item.Tags(); slices.Contains(tags, 1) {
+			t.Errorf("item %d should not have tag 1: %v", item.ID(), tags)
+		}
+	}
+
+	if LibraryItem(items[1]).Path() != "/tv/B" {
+		t.Error("items that are not selected must not change")
+	}
+
+	invalid := map[string]*LibraryEdit{
+		"no ids":      {QualityProfileID: 1},
+		"nothing":     {IDs: []int64{1}},
+		"series type": {IDs: []int64{1}, SeriesType: "movie"},
+		"metadata":    {IDs: []int64{1}, MetadataProfileID: 1},
+		"apply tags":  {IDs: []int64{1}, Tags: []int64{1}, ApplyTags: "merge"},
+	}
+
+	for name, edit := range invalid {
+		if _, err := test.EditLibrary(config, edit); err == nil {
+			t.Errorf("%s: an invalid edit should return an error", name)
+		}
+	}
+
+	radarr := *config
+	radarr.App = starr.Radarr.String()
+
+	if _, err := test.EditLibrary(&radarr, &LibraryEdit{IDs: []int64{1}, SeriesType: "daily"}); err == nil {
+		t.Error("radarr has no series type")
+	}
+}
+
+// TestEditMovies edits movies with the starr library's movie editor.
+func TestEditMovies(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	t.Cleanup(server.Close)
+
+	server.Seed("movie", starrtest.Item{"id": 1, "title": "A", "path": "/movies/A", "qualityProfileId": 1})
+
+	reply, err := test.EditLibrary(newConfig(server), &LibraryEdit{IDs: []int64{1}, QualityProfileID: 3})
+	if err != nil || len(reply.Items) != 1 {
+		t.Fatalf("the movie should be edited: %v, %v", err, reply)
+	}
+
+	if movie := LibraryItem(server.Items("movie")[0]); movie.Int("qualityProfileId") != 3 ||
+		!server.Called("PUT /api/v3/movie/editor") {
+		t.Errorf("the movie editor should change the profile: %v", movie)
+	}
+}
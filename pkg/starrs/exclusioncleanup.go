@@ -0,0 +1,179 @@
+package starrs
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* Find duplicate, conflicting and stale exclusions, and delete them in bulk. */
+
+// AnalyzeExclusions finds duplicate exclusions, exclusions for items that are in the library,
+// and with checkIDs, exclusions with ids the app's search no longer finds. Only exclusions
+// with a problem are returned. Duplicates keep the exclusion with the lowest id.
+func (s *Starrs) AnalyzeExclusions(config *AppConfig, checkIDs bool) (*ExclusionPlan, error) {
+	s.log.Tracef("Call:AnalyzeExclusions(%s, %s, %v)", config.App, config.Name, checkIDs)
+
+	api := getExclusionAPI(config.App)
+	if api == nil {
+		return nil, errors.New(s.log.Translate("%s has no import list exclusions.", config.App))
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := instance.analyzeExclusions(api, checkIDs)
+	if err != nil {
+		msg := s.log.Translate("Analyzing %s import list exclusions: %v", config.Name, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &ExclusionPlan{
+		Msg: s.log.Translate("Found %d duplicate, %d conflicting and %d stale import list exclusions.",
+			countStatus(entries, ExclusionDuplicate), countStatus(entries, ExclusionConflict),
+			countStatus(entries, ExclusionStale)),
+		Entries: entries,
+	}, nil
+}
+
+func (i *instance) analyzeExclusions(api *exclusionAPI, checkIDs bool) ([]*ExclusionEntry, error) {
+	entries, err := i.exclusionEntries(api)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := i.library()
+	if err != nil {
+		return nil, err
+	}
+
+	lib := getLibrary(i.config.App)
+	inLibrary := make(map[string]string, len(items))
+
+	for _, item := range items {
+		inLibrary[item.String(lib.Foreign)] = item.String(lib.Title)
+	}
+
+	slices.SortFunc(entries, func(a, b *ExclusionEntry) int { return cmp.Compare(a.ID, b.ID) })
+
+	seen := make(map[string]int64, len(entries))
+	problems := []*ExclusionEntry{}
+
+	for _, entry := range entries {
+		if first, ok := seen[entry.ForeignID]; ok {
+			entry.Status, entry.Msg = ExclusionDuplicate, i.log.Translate("Same %s as exclusion %d.", api.Foreign, first)
+		} else if title, ok := inLibrary[entry.ForeignID]; ok {
+			entry.Status, entry.Msg = ExclusionConflict, i.log.Translate("%s is in the library.", title)
+		} else if checkIDs {
+			if _, err := i.lookup(api.Lookup + entry.ForeignID); errors.Is(err, ErrNotFound) {
+				entry.Status, entry.Msg = ExclusionStale, i.log.Translate("%s %s was not found.", api.Foreign, entry.ForeignID)
+			} else if err != nil {
+				return nil, err
+			}
+		}
+
+		seen[entry.ForeignID] = entry.ID
+
+		if entry.Status != "" {
+			problems = append(problems, entry)
+		}
+	}
+
+	return problems, nil
+}
+
+// DeleteExclusions deletes the selected exclusions. Radarr and Sonarr delete them all at once
+// with their bulk endpoint; the other apps delete them one at a time.
+func (s *Starrs) DeleteExclusions(config *AppConfig, selected Selected) (*DataReply, error) {
+	s.log.Tracef("Call:DeleteExclusions(%s, %s, %d)", config.App, config.Name, selected.Count())
+
+	api := getExclusionAPI(config.App)
+	if api == nil {
+		return nil, errors.New(s.log.Translate("%s has no import list exclusions.", config.App))
+	}
+
+	ids := []int64{}
+
+	for id, ok := range selected {
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New(s.log.Translate("Select exclusions to delete."))
+	}
+
+	slices.Sort(ids)
+
+	question := s.log.Translate("Really delete %d import list exclusions from %s?", len(ids), config.Name)
+	if !s.app.Ask(s.log.Translate("Delete Exclusions"), question) {
+		return &DataReply{}, nil
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().Add(waitTime)
+	// Svelte just won't update some reactive variables if you return quickly.
+	defer func() { time.Sleep(time.Until(end)) }()
+
+	if err := instance.deleteExclusions(api, ids); err != nil {
+		msg := s.log.Translate("Deleting %s import list exclusions: %v", config.Name, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &DataReply{
+		Msg:  s.log.Translate("Deleted %d import list exclusions from %s.", len(ids), config.Name),
+		Data: ids,
+	}, nil
+}
+
+func (i *instance) deleteExclusions(api *exclusionAPI, ids []int64) error {
+	if api.BulkDel {
+		// The starr library deletes exclusions one at a time; it has no call for the bulk endpoint.
+		return i.deleteAny(i.ctx, api.URI+"/bulk", map[string][]int64{"ids": ids})
+	}
+
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		return lidarr.New(i.Config).DeleteExclusionsContext(i.ctx, ids)
+	case starr.Radarr:
+		return radarr.New(i.Config).DeleteExclusionsContext(i.ctx, ids)
+	case starr.Readarr:
+		return readarr.New(i.Config).DeleteExclusionsContext(i.ctx, ids)
+	case starr.Sonarr, starr.Whisparr:
+		return sonarr.New(i.Config).DeleteExclusionsContext(i.ctx, ids)
+	default:
+		return fmt.Errorf("%w: %s has no import list exclusions", ErrInvalidApp, i.config.App)
+	}
+}
+
+// countStatus counts the entries with a status.
+func countStatus(entries []*ExclusionEntry, status string) int {
+	count := 0
+
+	for _, entry := range entries {
+		if entry.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,100 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestAnalyzeExclusions(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	server.Seed("exclusions",
+		starrtest.Item{"id": 1, "tmdbId": 1, "movieTitle": "Kept"},
+		starrtest.Item{"id": 2, "tmdbId": 1, "movieTitle": "Duplicate"},
+		starrtest.Item{"id": 3, "tmdbId": 5, "movieTitle": "Conflict"},
+		starrtest.Item{"id": 4, "tmdbId": 9, "movieTitle": "Stale"},
+		starrtest.Item{"id": 5, "tmdbId": 7, "movieTitle": "Fine"},
+	)
+	server.Seed("movie", starrtest.Item{"id": 10, "tmdbId": 5, "title": "In Library"})
+	server.Seed("movie/lookup",
+		starrtest.Item{"tmdbId": 1, "title": "Kept"},
+		starrtest.Item{"tmdbId": 7, "title": "Fine"},
+	)
+	config := newConfig(server)
+
+	plan, err := test.AnalyzeExclusions(config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Entries) != 2 {
+		t.Errorf("without checking ids, stale exclusions should not be found: %+v", plan.Entries)
+	}
+
+	if plan, err = test.AnalyzeExclusions(config, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]string{2: ExclusionDuplicate, 3: ExclusionConflict, 4: ExclusionStale}
+	if len(plan.Entries) != len(want) {
+		t.Fatalf("wanted %d problems, got: %+v", len(want), plan.Entries)
+	}
+
+	selected := Selected{}
+
+	for _, entry := range plan.Entries {
+		if entry.Status != want[entry.ID] {
+			t.Errorf("exclusion %d: wanted status %s, got: %s", entry.ID, want[entry.ID], entry.Status)
+		}
+
+		selected[entry.ID] = true
+	}
+
+	if _, err := test.DeleteExclusions(config, selected); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.Called("DELETE /api/v3/exclusions/bulk") {
+		t.Error("radarr exclusions should be deleted with the bulk endpoint")
+	}
+
+	if left := server.Items("exclusions"); len(left) != 2 || left[0].ID() != 1 || left[1].ID() != 5 {
+		t.Errorf("only the selected exclusions should be deleted: %v", left)
+	}
+}
+
+func TestDeleteExclusionsOneByOne(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Lidarr)
+	defer server.Close()
+
+	server.Seed("importlistexclusion",
+		starrtest.Item{"id": 1, "foreignId": "a", "artistName": "A"},
+		starrtest.Item{"id": 2, "foreignId": "b", "artistName": "B"},
+	)
+	config := newConfig(server)
+
+	if _, err := test.DeleteExclusions(config, Selected{1: true, 2: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	if left := server.Items("importlistexclusion"); len(left) != 1 || left[0].ID() != 2 {
+		t.Errorf("only the selected exclusion should be deleted: %v", left)
+	}
+
+	if server.Called("DELETE /api/v1/importlistexclusion/bulk") {
+		t.Error("lidarr has no bulk delete endpoint")
+	}
+
+	if _, err := test.DeleteExclusions(config, Selected{}); err == nil {
+		t.Error("deleting nothing should return an error")
+	}
+}
@@ -0,0 +1,616 @@
+package starrs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* Bulk import exclusions from CSV files, id lists, Trakt and IMDb exports, and other instances. */
+
+// ErrUnknownFormat is returned for an exclusion list in a format that cannot be read.
+var ErrUnknownFormat = errors.New("unknown list format")
+
+// Exclusion entry statuses.
+const (
+	ExclusionAdd       = "add"       // Preview: the exclusion will be added.
+	ExclusionExists    = "exists"    // The app already has an exclusion for this id.
+	ExclusionDuplicate = "duplicate" // The id is in the list more than once.
+	ExclusionInvalid   = "invalid"   // The entry has no usable id. Msg says why.
+	ExclusionAdded     = "added"     // The exclusion was added.
+	ExclusionFailed    = "failed"    // Adding the exclusion failed. Msg has the error.
+	ExclusionConflict  = "conflict"  // Cleanup: the excluded item is in the library.
+	ExclusionStale     = "stale"     // Cleanup: the id is no longer found with the app's search.
+)
+
+// Exclusion import formats.
+const (
+	FormatCSV      = "csv"      // A header row with an id column, and optional title and year columns.
+	FormatIDs      = "ids"      // Ids separated by commas, spaces or new lines. # starts a comment.
+	FormatTrakt    = "trakt"    // A Trakt list export in json.
+	FormatIMDb     = "imdb"     // An IMDb list export in CSV. Ids are found with the app's search.
+	FormatInstance = "instance" // The exclusions from another instance of the same app.
+)
+
+// ExclusionEntry is an exclusion in any app.
+type ExclusionEntry struct {
+	ID        int64  // Exclusion id in the app. 0 for new entries.
+	ForeignID string // TMDB, TVDB, MusicBrainz or Goodreads id.
+	IMDb      string // IMDb id, from an IMDb or Trakt export, used to find the ForeignID.
+	Title     string
+	Year      int64
+	Status    string
+	Msg       string
+}
+
+// ExclusionImport is a list of exclusions to import.
+type ExclusionImport struct {
+	Format  string     // csv, ids, trakt, imdb or instance.
+	Data    string     // The list. A file is picked when this is empty.
+	Source  *AppConfig // Instance to copy exclusions from, for the instance format.
+	Resolve bool       // Find missing titles with the app's search.
+}
+
+// ExclusionPlan is the preview of an exclusion import, or the result of one.
+type ExclusionPlan struct {
+	Msg     string
+	Entries []*ExclusionEntry
+}
+
+// exclusionAPI describes an app's exclusion API.
+type exclusionAPI struct {
+	URI     string   // API path.
+	Foreign string   // Field with the metadata id.
+	Numeric bool     // The metadata id is a number.
+	Title   string   // Field with the title.
+	Year    string   // Field with the year. Empty if the app has none.
+	Bulk    bool     // Has a bulk add endpoint.
+	BulkDel bool     // Has a bulk delete endpoint.
+	Lookup  string   // Search term prefix to find an item by metadata id.
+	Trakt   string   // Key in a Trakt ids object. Empty if Trakt has no ids for the app.
+	Columns []string // CSV column names with the metadata id, lowercase without spaces.
+}
+
+// getExclusionAPI returns the exclusion API for an app. Returns nil for Prowlarr.
+func getExclusionAPI(app string) *exclusionAPI {
+	switch starr.App(app) {
+	case starr.Lidarr:
+		return &exclusionAPI{
+			URI: "importlistexclusion", Foreign: "foreignId", Title: "artistName", Lookup: "lidarr:",
+			Columns: []string{"foreignid", "mbid", "musicbrainzid", "artistid", "id"},
+		}
+	case starr.Radarr:
+		return &exclusionAPI{
+			URI: "exclusions", Foreign: "tmdbId", Numeric: true, Title: "movieTitle", Year: "movieYear",
+			Bulk: true, BulkDel: true, Lookup: "tmdb:", Trakt: "tmdb", Columns: []string{"tmdbid", "tmdb", "id"},
+		}
+	case starr.Readarr:
+		return &exclusionAPI{
+			URI: "importlistexclusion", Foreign: "foreignId", Title: "authorName", Lookup: "readarr:",
+			Columns: []string{"foreignid", "goodreadsid", "authorid", "id"},
+		}
+	case starr.Sonarr, starr.Whisparr:
+		return &exclusionAPI{
+			URI: "importlistexclusion", Foreign: "tvdbId", Numeric: true, Title: "title", Lookup: "tvdb:",
+			Trakt: "tvdb", Columns: []string{"tvdbid", "tvdb", "id"}, BulkDel: app == starr.Sonarr.String(),
+		}
+	default:
+		return nil
+	}
+}
+
+// PreviewExclusionImport reads a list of exclusions, and marks the ones the app already has,
+// duplicates and entries without a usable id. Nothing is changed.
+func (s *Starrs) PreviewExclusionImport(config *AppConfig, input *ExclusionImport) (*ExclusionPlan, error) {
+	s.log.Tracef("Call:PreviewExclusionImport(%s, %s, %s)", config.App, config.Name, input.Format)
+
+	api := getExclusionAPI(config.App)
+	if api == nil {
+		return nil, errors.New(s.log.Translate("%s has no import list exclusions.", config.App))
+	}
+
+	entries, err := s.readExclusions(config, api, input)
+	if err != nil {
+		return nil, err
+	} else if entries == nil {
+		return &ExclusionPlan{Entries: []*ExclusionEntry{}}, nil // File picker was canceled.
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// IMDb ids are looked up first, so they can be checked for duplicates.
+	if err := instance.resolveExclusions(api, entries, false); err != nil {
+		return nil, errors.New(s.log.Translate("Looking up exclusions: %v", err.Error()))
+	}
+
+	plan, err := instance.planExclusions(api, entries)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Getting import list exclusions: %v", err.Error()))
+	}
+
+	if !input.Resolve {
+		return plan, nil
+	}
+
+	if err := instance.resolveExclusions(api, entries, true); err != nil {
+		return nil, errors.New(s.log.Translate("Looking up exclusions: %v", err.Error()))
+	}
+
+	// A lookup may return a different id than the one provided, so duplicates are checked again.
+	if plan, err = instance.planExclusions(api, entries); err != nil {
+		return nil, errors.New(s.log.Translate("Getting import list exclusions: %v", err.Error()))
+	}
+
+	return plan, nil
+}
+
+// BulkAddExclusions adds exclusions from a preview. Entries the app already has are skipped.
+// Radarr adds them all at once; the other apps add them one at a time.
+func (s *Starrs) BulkAddExclusions(config *AppConfig, entries []*ExclusionEntry) (*ExclusionPlan, error) {
+	s.log.Tracef("Call:BulkAddExclusions(%s, %s, %d)", config.App, config.Name, len(entries))
+
+	api := getExclusionAPI(config.App)
+	if api == nil {
+		return nil, errors.New(s.log.Translate("%s has no import list exclusions.", config.App))
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := instance.planExclusions(api, entries)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Getting import list exclusions: %v", err.Error()))
+	}
+
+	question := s.log.Translate("Add import list exclusions to %s?\n%s", config.Name, plan.Msg)
+	if !s.app.Ask(s.log.Translate("Import Exclusions"), question) {
+		return &ExclusionPlan{Entries: []*ExclusionEntry{}}, nil
+	}
+
+	end := time.Now().Add(waitTime)
+	// Svelte just won't update some reactive variables if you return quickly.
+	defer func() { time.Sleep(time.Until(end)) }()
+
+	instance.addExclusions(api, plan.Entries)
+	plan.Msg = s.exclusionSummary(plan.Entries)
+
+	return plan, nil
+}
+
+// readExclusions reads the import list, from the input, a file or another instance.
+// Returns nil entries if the file picker was canceled.
+func (s *Starrs) readExclusions(
+	config *AppConfig,
+	api *exclusionAPI,
+	input *ExclusionImport,
+) ([]*ExclusionEntry, error) {
+	if input.Format == FormatInstance {
+		if input.Source == nil || input.Source.App != config.App {
+			return nil, errors.New(s.log.Translate("Pick another %s instance to copy exclusions from.", config.App))
+		}
+
+		source, err := s.newAPIinstance(input.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := source.exclusionEntries(api)
+		if err != nil {
+			return nil, errors.New(s.log.Translate("Getting %s import list exclusions: %v", input.Source.Name, err.Error()))
+		}
+
+		return entries, nil
+	}
+
+	if input.Data == "" {
+		data, err := s.openExclusionFile(config)
+		if err != nil || data == "" {
+			return nil, err
+		}
+
+		input.Data = data
+	}
+
+	entries, err := parseExclusions(api, input.Format, input.Data)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Reading %s list: %v", input.Format, err.Error()))
+	}
+
+	return entries, nil
+}
+
+// openExclusionFile asks for a file and returns its contents. Returns an empty string if canceled.
+func (s *Starrs) openExclusionFile(config *AppConfig) (string, error) {
+	filePath, err := s.app.OpenFile(&mnd.FileDialog{
+		Directory: lastPickedDir,
+		Filename:  config.App + Exclusions,
+		Title:     s.log.Translate("Select Exclusion List"),
+		Filters:   []mnd.FileFilter{{DisplayName: "Lists (*.csv, *.json, *.txt)", Pattern: "*.csv;*.json;*.txt"}},
+	})
+	if err != nil {
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Opening file browser: %v", err))
+	} else if filePath == "" {
+		return "", nil
+	}
+
+	lastPickedDir = filepath.Dir(filePath)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", errors.New(s.log.Translate("Opening file: %v", err))
+	}
+
+	return string(data), nil
+}
+
+// parseExclusions reads a list of exclusions in one of the import formats.
+func parseExclusions(api *exclusionAPI, format, data string) ([]*ExclusionEntry, error) {
+	switch format {
+	case FormatIDs:
+		return parseIDList(data), nil
+	case FormatCSV, FormatIMDb:
+		return parseExclusionCSV(api, data)
+	case FormatTrakt:
+		return parseTrakt(api, data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// parseIDList reads ids separated by commas, spaces or new lines. # starts a comment.
+func parseIDList(data string) []*ExclusionEntry {
+	entries := []*ExclusionEntry{}
+
+	split := func(r rune) bool { return r == ',' || r == ';' || unicode.IsSpace(r) }
+
+	for _, line := range strings.Split(data, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+
+		for _, id := range strings.FieldsFunc(line, split) {
+			entries = append(entries, newExclusionEntry(id))
+		}
+	}
+
+	return entries
+}
+
+// newExclusionEntry returns an entry for an id. IMDb ids go in the IMDb field.
+func newExclusionEntry(id string) *ExclusionEntry {
+	if strings.HasPrefix(id, "tt") {
+		return &ExclusionEntry{IMDb: id}
+	}
+
+	return &ExclusionEntry{ForeignID: id}
+}
+
+// parseExclusionCSV reads a CSV file with a header row. Column names are matched without case,
+// spaces or underscores. IMDb list exports have the IMDb id in the Const column.
+func parseExclusionCSV(api *exclusionAPI, data string) ([]*ExclusionEntry, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv: %w", err)
+	} else if len(rows) == 0 {
+		return []*ExclusionEntry{}, nil
+	}
+
+	column := func(names ...string) int {
+		for _, name := range names {
+			for idx, header := range rows[0] {
+				if strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(header)) == name {
+					return idx
+				}
+			}
+		}
+
+		return -1
+	}
+
+	foreign, imdb := column(api.Columns...), column("const", "imdbid", "imdb")
+	title, year := column(strings.ToLower(api.Title), "title", "name"), column("year")
+
+	if foreign < 0 && imdb < 0 {
+		return nil, fmt.Errorf("%w: no id column found in: %s", ErrNotFound, strings.Join(rows[0], ", "))
+	}
+
+	entries := []*ExclusionEntry{}
+	get := func(row []string, idx int) string {
+		if idx < 0 || idx >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[idx])
+	}
+
+	for _, row := range rows[1:] {
+		entry := &ExclusionEntry{ForeignID: get(row, foreign), IMDb: get(row, imdb), Title: get(row, title)}
+		entry.Year, _ = strconv.ParseInt(get(row, year), 10, 64)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// traktItem is an entry in a Trakt list export. Movies and shows have the same fields.
+type traktItem struct {
+	Title string         `json:"title"`
+	Year  int64          `json:"year"`
+	IDs   map[string]any `json:"ids"`
+}
+
+// parseTrakt reads a Trakt list export: a json list of entries with a movie or a show.
+func parseTrakt(api *exclusionAPI, data string) ([]*ExclusionEntry, error) {
+	list := []struct {
+		Movie *traktItem `json:"movie"`
+		Show  *traktItem `json:"show"`
+		*traktItem
+	}{}
+
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, fmt.Errorf("reading trakt json: %w", err)
+	}
+
+	entries := []*ExclusionEntry{}
+
+	for _, row := range list {
+		item := row.traktItem
+		if row.Movie != nil {
+			item = row.Movie
+		} else if row.Show != nil {
+			item = row.Show
+		}
+
+		if item == nil {
+			continue
+		}
+
+		entry := &ExclusionEntry{Title: item.Title, Year: item.Year}
+		if id, ok := item.IDs[api.Trakt]; ok && api.Trakt != "" && id != nil {
+			entry.ForeignID = LibraryItem{"id": id}.String("id")
+		}
+
+		entry.IMDb, _ = item.IDs["imdb"].(string)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// resolveExclusions finds metadata ids for IMDb entries. With titles, it finds the titles
+// for new entries without one instead; entries that are not found are invalid. The lookup
+// may return a different id, so plan the entries again after finding titles.
+func (i *instance) resolveExclusions(api *exclusionAPI, entries []*ExclusionEntry, titles bool) error {
+	lib := getLibrary(i.config.App)
+
+	for _, entry := range entries {
+		term := ""
+
+		switch {
+		case !titles && entry.ForeignID == "" && entry.IMDb != "" && api.Trakt != "":
+			term = "imdb:" + entry.IMDb
+		case titles && entry.Status == ExclusionAdd && entry.Title == "":
+			term = api.Lookup + entry.ForeignID
+		default:
+			continue
+		}
+
+		item, err := i.lookup(term)
+		if errors.Is(err, ErrNotFound) {
+			entry.Status, entry.Msg = ExclusionInvalid, i.log.Translate("Not found: %s", term)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		entry.ForeignID = item.String(lib.Foreign)
+		entry.Title = item.String(lib.Title)
+		entry.Year = item.Int("year")
+	}
+
+	return nil
+}
+
+// exclusionEntries returns the exclusions in an app.
+func (i *instance) exclusionEntries(api *exclusionAPI) ([]*ExclusionEntry, error) {
+	exclusions, err := i.exclusions(i.config)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := toItems(exclusions)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*ExclusionEntry, len(list))
+	for idx, item := range list {
+		entries[idx] = &ExclusionEntry{
+			ID:        item.ID(),
+			ForeignID: item.String(api.Foreign),
+			Title:     item.String(api.Title),
+			Year:      item.Int(api.Year),
+		}
+	}
+
+	return entries, nil
+}
+
+// planExclusions sets the status on each entry, and returns them in a plan.
+func (i *instance) planExclusions(api *exclusionAPI, entries []*ExclusionEntry) (*ExclusionPlan, error) {
+	existing, err := i.exclusionEntries(api)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make(map[string]bool, len(existing))
+	for _, entry := range existing {
+		have[entry.ForeignID] = true
+	}
+
+	seen := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		entry.ID, entry.ForeignID = 0, strings.TrimSpace(entry.ForeignID)
+
+		switch _, err := strconv.ParseInt(entry.ForeignID, 10, 64); {
+		case entry.Status == ExclusionInvalid:
+		case entry.ForeignID == "":
+			entry.Status, entry.Msg = ExclusionInvalid, i.log.Translate("No %s found.", api.Foreign)
+		case api.Numeric && err != nil:
+			entry.Status, entry.Msg = ExclusionInvalid, i.log.Translate("%s must be a number.", api.Foreign)
+		case have[entry.ForeignID]:
+			entry.Status, entry.Msg = ExclusionExists, ""
+		case seen[entry.ForeignID]:
+			entry.Status, entry.Msg = ExclusionDuplicate, ""
+		default:
+			entry.Status, entry.Msg = ExclusionAdd, ""
+		}
+
+		seen[entry.ForeignID] = true
+	}
+
+	return &ExclusionPlan{Msg: i.exclusionSummary(entries), Entries: entries}, nil
+}
+
+// addExclusions adds the entries with the add status. Radarr has a bulk endpoint.
+func (i *instance) addExclusions(api *exclusionAPI, entries []*ExclusionEntry) {
+	add := slices.DeleteFunc(slices.Clone(entries), func(e *ExclusionEntry) bool { return e.Status != ExclusionAdd })
+	if len(add) == 0 {
+		return
+	}
+
+	body := func(entry *ExclusionEntry) map[string]any {
+		item := map[string]any{api.Foreign: entry.ForeignID, api.Title: entry.Title}
+		if api.Numeric {
+			item[api.Foreign], _ = strconv.ParseInt(entry.ForeignID, 10, 64)
+		}
+
+		if api.Year != "" {
+			item[api.Year] = entry.Year
+		}
+
+		return item
+	}
+
+	if api.Bulk {
+		list := make([]map[string]any, len(add))
+		for idx, entry := range add {
+			list[idx] = body(entry)
+		}
+
+		exclusions := []*radarr.Exclusion{}
+
+		err := convert(list, &exclusions)
+		if err == nil {
+			err = radarr.New(i.Config).AddExclusionsContext(i.ctx, exclusions)
+		}
+
+		for _, entry := range add {
+			if entry.Status = ExclusionAdded; err != nil {
+				entry.Status, entry.Msg = ExclusionFailed, err.Error()
+			}
+		}
+
+		return
+	}
+
+	for _, entry := range add {
+		if id, err := i.addExclusionItem(body(entry)); err != nil {
+			entry.Status, entry.Msg = ExclusionFailed, err.Error()
+		} else {
+			entry.Status, entry.ID = ExclusionAdded, id
+		}
+	}
+}
+
+// addExclusionItem adds one exclusion with the starr library's exclusion type for the app, and returns its id.
+func (i *instance) addExclusionItem(item map[string]any) (int64, error) {
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		exclusion := &lidarr.Exclusion{}
+		if err := convert(item, exclusion); err != nil {
+			return 0, err
+		}
+
+		added, err := lidarr.New(i.Config).AddExclusionContext(i.ctx, exclusion)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	case starr.Radarr:
+		exclusion := &radarr.Exclusion{}
+		if err := convert(item, exclusion); err != nil {
+			return 0, err
+		}
+
+		added, err := radarr.New(i.Config).AddExclusionContext(i.ctx, exclusion)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	case starr.Readarr:
+		exclusion := &readarr.Exclusion{}
+		if err := convert(item, exclusion); err != nil {
+			return 0, err
+		}
+
+		added, err := readarr.New(i.Config).AddExclusionContext(i.ctx, exclusion)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	case starr.Sonarr, starr.Whisparr:
+		exclusion := &sonarr.Exclusion{}
+		if err := convert(item, exclusion); err != nil {
+			return 0, err
+		}
+
+		added, err := sonarr.New(i.Config).AddExclusionContext(i.ctx, exclusion)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	default:
+		return 0, fmt.Errorf("%w: %s has no import list exclusions", ErrInvalidApp, i.config.App)
+	}
+}
+
+// exclusionSummary counts the entries in each status.
+func (s *Starrs) exclusionSummary(entries []*ExclusionEntry) string {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Status]++
+	}
+
+	return s.log.Translate("Add: %d, added: %d, failed: %d, already excluded: %d, duplicates: %d, invalid: %d.",
+		counts[ExclusionAdd], counts[ExclusionAdded], counts[ExclusionFailed], counts[ExclusionExists],
+		counts[ExclusionDuplicate], counts[ExclusionInvalid])
+}
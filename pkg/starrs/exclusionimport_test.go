@@ -0,0 +1,164 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestParseExclusions(t *testing.T) {
+	t.Parallel()
+
+	radarr, lidarr := getExclusionAPI(starr.Radarr.String()), getExclusionAPI(starr.Lidarr.String())
+	tests := map[string]struct {
+		api    *exclusionAPI
+		format string
+		data   string
+		want   []ExclusionEntry
+	}{
+		"ids": {
+			api: radarr, format: FormatIDs, data: "1, 2;3\n# comment\n4 # five\ntt0005",
+			want: []ExclusionEntry{{ForeignID: "1"}, {ForeignID: "2"}, {ForeignID: "3"}, {ForeignID: "4"}, {IMDb: "tt0005"}},
+		},
+		"csv": {
+			api: radarr, format: FormatCSV, data: "Title,TMDB ID,Year\n\"Film, The\",10,1999\nOther,11,",
+			want: []ExclusionEntry{{ForeignID: "10", Title: "Film, The", Year: 1999}, {ForeignID: "11", Title: "Other"}},
+		},
+		"lidarr csv": {
+			api: lidarr, format: FormatCSV, data: "artist_name,MBID\nBand,abc-123",
+			want: []ExclusionEntry{{ForeignID: "abc-123", Title: "Band"}},
+		},
+		"imdb": {
+			api: radarr, format: FormatIMDb, data: "Position,Const,Created,Title,Year\n1,tt0001,2024-01-01,Film,2001",
+			want: []ExclusionEntry{{IMDb: "tt0001", Title: "Film", Year: 2001}},
+		},
+		"trakt": {
+			api: radarr, format: FormatTrakt,
+			data: `[{"type": "movie", "movie": {"title": "Film", "year": 2001, "ids": {"tmdb": 603, "imdb": "tt0133093"}}},
+				{"type": "show", "show": {"title": "Show", "ids": {"tvdb": 1}}}]`,
+			want: []ExclusionEntry{{ForeignID: "603", IMDb: "tt0133093", Title: "Film", Year: 2001}, {Title: "Show"}},
+		},
+	}
+
+	for name, check := range tests {
+		entries, err := parseExclusions(check.api, check.format, check.data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(entries) != len(check.want) {
+			t.Fatalf("%s: wanted %d entries, got: %d", name, len(check.want), len(entries))
+		}
+
+		for idx, entry := range entries {
+			if *entry != check.want[idx] {
+				t.Errorf("%s: entry %d: wanted %+v, got: %+v", name, idx, check.want[idx], *entry)
+			}
+		}
+	}
+
+	if _, err := parseExclusions(radarr, FormatCSV, "Name,Year\nFilm,2001"); err == nil {
+		t.Error("a csv file without an id column should return an error")
+	}
+
+	if _, err := parseExclusions(radarr, "xml", ""); err == nil {
+		t.Error("an unknown format should return an error")
+	}
+}
+
+func TestImportExclusionList(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	server.Seed("exclusions", starrtest.Item{"id": 1, "tmdbId": 1, "movieTitle": "One"})
+	server.Seed("movie/lookup",
+		starrtest.Item{"title": "Two", "tmdbId": 2, "year": 2002},
+		starrtest.Item{"title": "Three", "tmdbId": 3, "imdbId": "tt0003"},
+		starrtest.Item{"title": "One", "tmdbId": 1, "oldTmdbId": 5}, // 5 was merged into 1, which is excluded.
+	)
+	config := newConfig(server)
+
+	plan, err := test.PreviewExclusionImport(config, &ExclusionImport{
+		Format: FormatIDs, Data: "1 2 2 abc tt0003 tt0009 5", Resolve: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{ExclusionExists, ExclusionAdd, ExclusionDuplicate, ExclusionInvalid, ExclusionAdd, ExclusionInvalid,
+		ExclusionExists}
+	for idx, entry := range plan.Entries {
+		if entry.Status != want[idx] {
+			t.Errorf("entry %d: wanted status %s, got: %+v", idx, want[idx], entry)
+		}
+	}
+
+	if plan.Entries[1].Title != "Two" || plan.Entries[4].ForeignID != "3" {
+		t.Errorf("titles and ids should be found with a lookup: %+v, %+v", plan.Entries[1], plan.Entries[4])
+	}
+
+	if plan.Entries[6].ForeignID != "1" || len(plan.Entries) != len(want) {
+		t.Errorf("an id found with a lookup should be checked for duplicates: %+v", plan.Entries[6])
+	}
+
+	if plan, err = test.BulkAddExclusions(config, plan.Entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Entries[1].Status != ExclusionAdded || !server.Called("POST /api/v3/exclusions/bulk") {
+		t.Errorf("radarr exclusions should be added with the bulk endpoint: %+v", plan.Entries[1])
+	}
+
+	exclusions := server.Items("exclusions")
+	if len(exclusions) != 3 || exclusions[1]["tmdbId"] != float64(2) || exclusions[1]["movieTitle"] != "Two" ||
+		exclusions[1]["movieYear"] != float64(2002) {
+		t.Errorf("wrong exclusions added: %v", exclusions)
+	}
+}
+
+func TestImportExclusionsFromInstance(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	source, target := starrtest.New(starr.Sonarr), starrtest.New(starr.Sonarr)
+
+	defer source.Close()
+	defer target.Close()
+
+	source.Seed("importlistexclusion",
+		starrtest.Item{"id": 1, "tvdbId": 100, "title": "Shared"},
+		starrtest.Item{"id": 2, "tvdbId": 12345678, "title": "New"},
+	)
+	target.Seed("importlistexclusion", starrtest.Item{"id": 1, "tvdbId": 100, "title": "Shared"})
+
+	input := &ExclusionImport{Format: FormatInstance, Source: newConfig(source)}
+
+	plan, err := test.PreviewExclusionImport(newConfig(target), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Entries) != 2 || plan.Entries[0].Status != ExclusionExists || plan.Entries[1].ForeignID != "12345678" {
+		t.Fatalf("wrong plan: %+v", plan.Entries)
+	}
+
+	if _, err = test.BulkAddExclusions(newConfig(target), plan.Entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if exclusions := target.Items("importlistexclusion"); len(exclusions) != 2 || exclusions[1]["title"] != "New" {
+		t.Errorf("the new exclusion should be copied: %v", exclusions)
+	}
+
+	radarr := newConfig(source)
+	radarr.App = starr.Radarr.String()
+
+	input.Source = radarr
+	if _, err := test.PreviewExclusionImport(newConfig(target), input); err == nil {
+		t.Error("copying exclusions from another app should return an error")
+	}
+}
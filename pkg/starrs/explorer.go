@@ -0,0 +1,373 @@
+package starrs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+/* Read-only SQL explorer for starr databases. */
+
+const (
+	defaultQueryLimit   = 1000
+	maxQueryLimit       = 100000
+	defaultQueryTimeout = 10 * time.Second
+	maxQueryTimeout     = 5 * time.Minute
+)
+
+// Query is the input to run a query in the explorer.
+type Query struct {
+	SQL     string
+	Params  map[string]string // Named parameters, used as :name in the SQL.
+	Limit   int               // Maximum rows to return. Default 1000.
+	Timeout time.Duration     // How long the query may run. Default 10 seconds.
+}
+
+// QueryColumn is a column in a table or a query result.
+type QueryColumn struct {
+	Name string
+	Type string // INTEGER, REAL, TEXT, BLOB, DATETIME, etc. Empty if unknown.
+}
+
+// QueryResult is the output from a query, for a grid.
+type QueryResult struct {
+	Columns   []*QueryColumn
+	Rows      [][]any
+	Truncated bool // More rows than Limit were found.
+	Elapsed   string
+}
+
+// DBTables returns the tables in a database, and their columns.
+func (s *Starrs) DBTables(config *AppConfig) (map[string][]*QueryColumn, error) {
+	s.log.Tracef("Call:DBTables(%s, %s)", config.App, config.Name)
+
+	sql, err := s.newReadOnlySQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	tables, err := sql.RowsStringSlice(s.ctx, "SELECT name FROM sqlite_schema WHERE type='table' ORDER BY name")
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	}
+
+	output := make(map[string][]*QueryColumn)
+
+	for _, table := range tables {
+		if output[table], err = sql.ColumnTypes(s.ctx, table); err != nil {
+			return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		}
+	}
+
+	return output, nil
+}
+
+// RunQuery runs a query against a read-only connection to a database.
+// Anything that tries to write returns an error from sqlite.
+func (s *Starrs) RunQuery(config *AppConfig, query *Query) (*QueryResult, error) {
+	s.log.Tracef("Call:RunQuery(%s, %s, %d, %v)", config.App, config.Name, query.Limit, query.Timeout)
+
+	if query.SQL == "" {
+		return nil, errors.New(s.log.Translate("No query provided."))
+	}
+
+	sql, err := s.newReadOnlySQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	result, err := sql.Query(s.ctx, query)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	}
+
+	return result, nil
+}
+
+// ExportQuery runs a query and saves the result to a csv or json file.
+func (s *Starrs) ExportQuery(config *AppConfig, query *Query, format string) (string, error) {
+	s.log.Tracef("Call:ExportQuery(%s, %s, %s)", config.App, config.Name, format)
+
+	if format != "csv" && format != "json" {
+		return "", errors.New(s.log.Translate("Unknown export format: %s", format))
+	}
+
+	result, err := s.RunQuery(config, query)
+	if err != nil {
+		return "", err
+	}
+
+	filePath, err := s.app.SaveFile(&mnd.FileDialog{
+		Directory: lastPickedDir,
+		Filename:  fmt.Sprintf("%s_query.%s", config.Name, format),
+		Title:     s.log.Translate("Save %d rows", len(result.Rows)),
+	})
+	if err != nil {
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Opening file browser: %v", err))
+	} else if filePath == "" {
+		return "", nil
+	}
+
+	lastPickedDir = filepath.Dir(filePath)
+
+	fileOpen, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mnd.Mode0640)
+	if err != nil {
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Opening file: %v", err))
+	}
+	defer fileOpen.Close()
+
+	write := result.WriteJSON
+	if format == "csv" {
+		write = result.WriteCSV
+	}
+
+	if err = write(fileOpen); err != nil {
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Encoding and writing file: %v", err))
+	}
+
+	return s.log.Translate("Wrote %d rows to %s", len(result.Rows), filePath), nil
+}
+
+// WriteCSV writes the result with a header row.
+func (r *QueryResult) WriteCSV(output io.Writer) error {
+	writer := csv.NewWriter(output)
+	record := make([]string, len(r.Columns))
+
+	for idx, column := range r.Columns {
+		record[idx] = column.Name
+	}
+
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+
+	for _, row := range r.Rows {
+		for idx, value := range row {
+			record[idx] = csvValue(value)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("writing csv: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the result as a list of objects, one per row.
+func (r *QueryResult) WriteJSON(output io.Writer) error {
+	rows := make([]map[string]any, len(r.Rows))
+
+	for idx, row := range r.Rows {
+		rows[idx] = make(map[string]any, len(row))
+		for col, value := range row {
+			rows[idx][r.Columns[col].Name] = value
+		}
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(rows); err != nil {
+		return fmt.Errorf("writing json: %w", err)
+	}
+
+	return nil
+}
+
+func csvValue(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// Query runs any query with a row limit and a timeout, and returns typed columns.
+// Other databases cannot be attached on this connection, so a read-only connection stays read only.
+func (s *sqlConn) Query(ctx context.Context, query *Query) (*QueryResult, error) {
+	start := time.Now()
+	limit, timeout := queryLimits(query)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := s.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = sqlite.Limit(conn, sqlite3.SQLITE_LIMIT_ATTACHED, 0); err != nil {
+		return nil, fmt.Errorf("limiting attached databases: %w", err)
+	}
+
+	s.log.Debugf("Running Query: %s", query.SQL)
+
+	rows, err := conn.QueryContext(ctx, query.SQL, query.args()...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.SQL, err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.SQL, err)
+	}
+
+	result := &QueryResult{Columns: make([]*QueryColumn, len(types)), Rows: [][]any{}}
+	for idx, column := range types {
+		result.Columns[idx] = &QueryColumn{Name: column.Name(), Type: column.DatabaseTypeName()}
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+
+		row := make([]any, len(types))
+		pointers := make([]any, len(types))
+
+		for idx := range row {
+			pointers[idx] = &row[idx]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("%s: %w", query.SQL, err)
+		}
+
+		result.Rows = append(result.Rows, row)
+		result.typeColumns(row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query.SQL, err)
+	}
+
+	result.Elapsed = time.Since(start).Round(time.Millisecond).String()
+
+	return result, nil
+}
+
+// typeColumns fills in types for expression columns from the values in a row, and makes blobs printable.
+func (r *QueryResult) typeColumns(row []any) {
+	for idx, value := range row {
+		if blob, ok := value.([]byte); ok {
+			row[idx] = string(blob)
+		}
+
+		if r.Columns[idx].Type != "" || value == nil {
+			continue
+		}
+
+		switch value.(type) {
+		case int64:
+			r.Columns[idx].Type = "INTEGER"
+		case float64:
+			r.Columns[idx].Type = "REAL"
+		case []byte:
+			r.Columns[idx].Type = "BLOB"
+		case time.Time:
+			r.Columns[idx].Type = "DATETIME"
+		default:
+			r.Columns[idx].Type = "TEXT"
+		}
+	}
+}
+
+// args returns the named parameters. Numbers are passed as numbers, so they work in LIMIT and comparisons.
+func (q *Query) args() []any {
+	args := make([]any, 0, len(q.Params))
+
+	for name, value := range q.Params {
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			args = append(args, sql.Named(name, number))
+		} else if number, err := strconv.ParseFloat(value, 64); err == nil {
+			args = append(args, sql.Named(name, number))
+		} else {
+			args = append(args, sql.Named(name, value))
+		}
+	}
+
+	return args
+}
+
+// queryLimits returns the row limit and timeout for a query, with defaults and maximums applied.
+func queryLimits(query *Query) (int, time.Duration) {
+	limit, timeout := query.Limit, query.Timeout
+
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	} else if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	if timeout <= 0 {
+		timeout = defaultQueryTimeout
+	} else if timeout > maxQueryTimeout {
+		timeout = maxQueryTimeout
+	}
+
+	return limit, timeout
+}
+
+// ColumnTypes returns the columns in a table with their declared types.
+func (s *sqlConn) ColumnTypes(ctx context.Context, table string) ([]*QueryColumn, error) {
+	query := "SELECT name, type FROM pragma_table_info('" + Escape(table) + "')"
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+	defer rows.Close()
+
+	columns := []*QueryColumn{}
+
+	for rows.Next() {
+		var column QueryColumn
+		if err := rows.Scan(&column.Name, &column.Type); err != nil {
+			return nil, fmt.Errorf("%s: %w", query, err)
+		}
+
+		columns = append(columns, &column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+
+	return columns, nil
+}
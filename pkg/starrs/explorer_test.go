@@ -0,0 +1,117 @@
+package starrs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golift.io/starr"
+)
+
+func TestDBTables(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr)
+
+	tables, err := test.DBTables(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+
+	for _, column := range tables["Movies"] {
+		found = found || (column.Name == "Path" && column.Type == "TEXT")
+	}
+
+	if !found || len(tables["RootFolders"]) != 2 {
+		t.Errorf("wrong tables: %v", tables)
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr,
+		insert("Movies", 1, "/movies/a"), insert("Movies", 2, "/movies/b"), insert("Movies", 3, "/movies/c"))
+
+	if _, err := test.RunQuery(config, &Query{}); err == nil {
+		t.Error("an empty query should return an error")
+	}
+
+	result, err := test.RunQuery(config, &Query{SQL: "SELECT Id, Path, Id * 1.5 AS Half FROM Movies ORDER BY Id", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Truncated || len(result.Rows) != 2 || result.Rows[1][1] != "/movies/b" || result.Rows[1][2] != 3.0 {
+		t.Errorf("wrong rows: %+v", result)
+	}
+
+	types := []string{}
+	for _, column := range result.Columns {
+		types = append(types, column.Name+":"+column.Type)
+	}
+
+	if strings.Join(types, ",") != "Id:INTEGER,Path:TEXT,Half:REAL" {
+		t.Errorf("wrong column types: %v", types)
+	}
+}
+
+func TestRunQueryReadOnly(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr, insert("Movies", 1, "/movies/a"))
+	attach := filepath.Join(filepath.Dir(config.DBPath), "new.db")
+
+	for _, query := range []string{
+		"DELETE FROM Movies",
+		"UPDATE Movies SET Path = '/gone'",
+		"CREATE TABLE Evil (Id INTEGER)",
+		"PRAGMA query_only = 0; DELETE FROM Movies",
+		"ATTACH DATABASE '" + attach + "' AS evil",
+		"VACUUM",
+	} {
+		if _, err := test.RunQuery(config, &Query{SQL: query}); err == nil {
+			t.Errorf("a write should return an error: %s", query)
+		}
+	}
+
+	if paths := test.rows(t, config, "SELECT Path FROM Movies"); len(paths) != 1 || paths[0] != "/movies/a" {
+		t.Errorf("the database should not change: %v", paths)
+	}
+
+	if _, err := os.Stat(attach); err == nil {
+		t.Errorf("attaching should not create a database file")
+	}
+}
+
+func TestExportQuery(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr, insert("Movies", 1, "/movies/a, b's"))
+	query := &Query{SQL: "SELECT Id, Path FROM Movies"}
+
+	if _, err := test.ExportQuery(config, query, "xml"); err == nil {
+		t.Error("an unknown format should return an error")
+	}
+
+	for format, want := range map[string]string{
+		"csv":  "Id,Path\n1,\"/movies/a, b's\"\n",
+		"json": "[\n  {\n    \"Id\": 1,\n    \"Path\": \"/movies/a, b's\"\n  }\n]\n",
+	} {
+		if _, err := test.ExportQuery(config, query, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		data, err := os.ReadFile(test.host.SavePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != want {
+			t.Errorf("%s: wrong export:\n%s", format, data)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package starrs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+)
+
+/* Health checks for every configured instance. Powers the landing page dashboard. */
+
+const (
+	// healthWorkers is the maximum number of instances checked at the same time.
+	healthWorkers = 4
+	// healthTimeout is used for instances without a timeout.
+	healthTimeout = time.Minute
+)
+
+// Severity levels for health checks, lowest to highest.
+const (
+	SeverityOK      = "ok"
+	SeverityNotice  = "notice"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// severityRank allows comparing severities.
+//
+//nolint:gochecknoglobals,gomnd
+var severityRank = map[string]int{
+	SeverityOK:      0,
+	SeverityNotice:  1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// HealthCheck is a single item from an app's /health endpoint.
+type HealthCheck struct {
+	Source  string `json:"source"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	WikiURL string `json:"wikiUrl"`
+}
+
+// DiskSpace is a single item from an app's /diskspace endpoint.
+type DiskSpace struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
+	Free       string `json:"free"`  // formatted for humans.
+	Total      string `json:"total"` // formatted for humans.
+}
+
+// systemStatus is the data we need from the /system/status endpoint. Works for all apps.
+type systemStatus struct {
+	AppName      string    `json:"appName"`
+	InstanceName string    `json:"instanceName"`
+	Version      string    `json:"version"`
+	Branch       string    `json:"branch"`
+	StartTime    time.Time `json:"startTime"`
+	IsDocker     bool      `json:"isDocker"`
+	OsName       string    `json:"osName"`
+}
+
+// updateEntry is a single item from an app's /update endpoint.
+type updateEntry struct {
+	Version     string `json:"version"`
+	Branch      string `json:"branch"`
+	Installed   bool   `json:"installed"`
+	Installable bool   `json:"installable"`
+	Latest      bool   `json:"latest"`
+}
+
+// InstanceHealth is the health report for a single instance.
+type InstanceHealth struct {
+	App       string
+	Name      string
+	Version   string
+	Branch    string
+	Docker    bool
+	OS        string
+	Uptime    string
+	Update    string // Version of available update, if there is one.
+	Severity  string // Highest severity found for this instance.
+	Checks    []*HealthCheck
+	Disks     []*DiskSpace
+	Errors    []string // Problems reaching the instance.
+	Elapsed   string
+	CheckedAt time.Time
+}
+
+// HealthMatrix is the aggregated health report for all instances.
+type HealthMatrix struct {
+	Severity  string                       // Highest severity across all instances.
+	Counts    map[string]int               // Severity => count of instances at that severity.
+	Instances map[string][]*InstanceHealth // App => list of instance health reports.
+	Elapsed   string
+}
+
+// Health returns the health report for a single instance.
+func (s *Starrs) Health(config *AppConfig) *InstanceHealth {
+	s.log.Tracef("Call:Health(%s, %s)", config.App, config.Name)
+	return s.instanceHealth(config)
+}
+
+// HealthAll checks every provided instance concurrently and returns an aggregated report.
+func (s *Starrs) HealthAll(instances Instances) *HealthMatrix {
+	s.log.Tracef("Call:HealthAll(%d)", len(instances))
+
+	start := time.Now()
+	matrix := &HealthMatrix{
+		Severity:  SeverityOK,
+		Counts:    map[string]int{SeverityOK: 0, SeverityNotice: 0, SeverityWarning: 0, SeverityError: 0},
+		Instances: make(map[string][]*InstanceHealth),
+	}
+
+	input := make(chan *AppConfig)
+	output := make(chan *InstanceHealth)
+	wait := sync.WaitGroup{}
+
+	for range healthWorkers {
+		wait.Add(1)
+
+		go func() {
+			defer s.log.CapturePanic()
+			defer wait.Done()
+
+			for config := range input {
+				output <- s.instanceHealth(config)
+			}
+		}()
+	}
+
+	go func() {
+		for app := range instances {
+			for idx := range instances[app] {
+				input <- &instances[app][idx]
+			}
+		}
+
+		close(input)
+		wait.Wait()
+		close(output)
+	}()
+
+	for health := range output {
+		matrix.Instances[health.App] = append(matrix.Instances[health.App], health)
+		matrix.Counts[health.Severity]++
+
+		if severityRank[health.Severity] > severityRank[matrix.Severity] {
+			matrix.Severity = health.Severity
+		}
+	}
+
+	for app := range matrix.Instances {
+		sort.Slice(matrix.Instances[app], func(i, j int) bool {
+			return matrix.Instances[app][i].Name < matrix.Instances[app][j].Name
+		})
+	}
+
+	matrix.Elapsed = time.Since(start).Round(time.Millisecond).String()
+
+	return matrix
+}
+
+// instanceHealth collects system status, health, disk space and update info from one instance.
+// Errors are recorded in the output, so this method always returns something useful.
+func (s *Starrs) instanceHealth(config *AppConfig) *InstanceHealth {
+	start := time.Now()
+	health := &InstanceHealth{
+		App:       config.App,
+		Name:      config.Name,
+		Severity:  SeverityOK,
+		Checks:    []*HealthCheck{},
+		Disks:     []*DiskSpace{},
+		Errors:    []string{},
+		CheckedAt: start,
+	}
+
+	defer func() { health.Elapsed = time.Since(start).Round(time.Millisecond).String() }()
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = healthTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return health.addError(s.log.Translate("Connecting: %v", err.Error()))
+	}
+
+	var status systemStatus
+	if err := instance.getInto(ctx, "system/status", &status); err != nil {
+		return health.addError(s.log.Translate("Getting system status: %v", err.Error()))
+	}
+
+	health.Version = status.Version
+	health.Branch = status.Branch
+	health.Docker = status.IsDocker
+	health.OS = status.OsName
+
+	if !status.StartTime.IsZero() {
+		health.Uptime = time.Since(status.StartTime).Round(time.Second).String()
+	}
+
+	if err := instance.getInto(ctx, "health", &health.Checks); err != nil {
+		health.addError(s.log.Translate("Getting health: %v", err.Error()))
+	}
+
+	for _, check := range health.Checks {
+		health.raise(check.Type)
+	}
+
+	if starr.App(config.App) != starr.Prowlarr { // prowlarr has no disks.
+		if err := instance.getInto(ctx, "diskspace", &health.Disks); err != nil {
+			health.addError(s.log.Translate("Getting disk space: %v", err.Error()))
+		}
+	}
+
+	for _, disk := range health.Disks {
+		s.checkDisk(health, disk)
+	}
+
+	health.Update, err = instance.checkUpdate(ctx)
+	if err != nil {
+		health.addError(s.log.Translate("Checking for updates: %v", err.Error()))
+	} else if health.Update != "" {
+		health.raise(SeverityNotice)
+	}
+
+	return health
+}
+
+// checkUpdate returns the version of an available update, or an empty string.
+func (i *instance) checkUpdate(ctx context.Context) (string, error) {
+	var updates []*updateEntry
+	if err := i.getInto(ctx, "update", &updates); err != nil {
+		return "", err
+	}
+
+	for _, update := range updates {
+		if update.Latest && !update.Installed && update.Installable {
+			return update.Version, nil
+		}
+	}
+
+	return "", nil
+}
+
+// lowDiskPercent is the percent of free space that triggers a disk space warning.
+const lowDiskPercent = 5
+
+// checkDisk formats the disk sizes and adds a warning to the health report if the disk is almost full.
+func (s *Starrs) checkDisk(health *InstanceHealth, disk *DiskSpace) {
+	disk.Free = mnd.FormatBytes(max(disk.FreeSpace, 0))
+	disk.Total = mnd.FormatBytes(max(disk.TotalSpace, 0))
+
+	if disk.TotalSpace > 0 && disk.FreeSpace*100/disk.TotalSpace < lowDiskPercent {
+		health.Checks = append(health.Checks, &HealthCheck{
+			Source:  "DiskSpace",
+			Type:    SeverityWarning,
+			Message: s.log.Translate("Low disk space on %s: %s free of %s", disk.Path, disk.Free, disk.Total),
+		})
+		health.raise(SeverityWarning)
+	}
+}
+
+// addError records an error and raises the severity to error.
+func (h *InstanceHealth) addError(msg string) *InstanceHealth {
+	h.Errors = append(h.Errors, msg)
+	h.raise(SeverityError)
+
+	return h
+}
+
+// raise increases the severity if the provided severity is higher than the current one.
+func (h *InstanceHealth) raise(severity string) {
+	if _, ok := severityRank[severity]; !ok {
+		severity = SeverityWarning // unknown types are treated as warnings.
+	}
+
+	if severityRank[severity] > severityRank[h.Severity] {
+		h.Severity = severity
+	}
+}
@@ -0,0 +1,163 @@
+package starrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	tests := []struct {
+		desc     string
+		app      starr.App
+		seed     map[string][]starrtest.Item
+		severity string
+		checks   int
+	}{
+		{desc: "healthy", app: starr.Sonarr, severity: SeverityOK},
+		{
+			desc: "update available", app: starr.Radarr, severity: SeverityNotice,
+			seed: map[string][]starrtest.Item{"update": {
+				{"version": "9.9.9", "latest": true, "installable": true},
+				{"version": "1.0.0", "installed": true},
+			}},
+		},
+		{
+			desc: "installed update", app: starr.Radarr, severity: SeverityOK,
+			seed: map[string][]starrtest.Item{"update": {{"version": "9.9.9", "latest": true, "installed": true}}},
+		},
+		{
+			desc: "low disk space", app: starr.Lidarr, severity: SeverityWarning, checks: 1,
+			seed: map[string][]starrtest.Item{"diskspace": {
+				{"path": "/music", "freeSpace": 4, "totalSpace": 100},
+				{"path": "/backup", "freeSpace": 50, "totalSpace": 100},
+			}},
+		},
+		{
+			desc: "prowlarr has no disks", app: starr.Prowlarr, severity: SeverityOK,
+			seed: map[string][]starrtest.Item{"diskspace": {{"path": "/", "freeSpace": 0, "totalSpace": 100}}},
+		},
+		{
+			desc: "unknown check type is a warning", app: starr.Readarr, severity: SeverityWarning, checks: 1,
+			seed: map[string][]starrtest.Item{"health": {{"source": "Indexer", "type": "strange"}}},
+		},
+		{
+			desc: "highest check wins", app: starr.Whisparr, severity: SeverityError, checks: 3,
+			seed: map[string][]starrtest.Item{
+				"health": {{"type": SeverityNotice}, {"type": SeverityError}, {"type": SeverityWarning}},
+				"update": {{"version": "9.9.9", "latest": true, "installable": true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		server := starrtest.New(tt.app)
+		defer server.Close()
+
+		for resource, items := range tt.seed {
+			server.Seed(resource, items...)
+		}
+
+		health := test.Health(newConfig(server))
+		if health.Severity != tt.severity || len(health.Checks) != tt.checks || len(health.Errors) != 0 {
+			t.Errorf("%s: wrong severity or checks: %+v", tt.desc, health)
+		}
+	}
+}
+
+func TestHealthTimeout(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Sonarr)
+	defer server.Close()
+
+	config := newConfig(server)
+	config.Timeout = 0
+
+	if health := test.Health(config); health.Severity != SeverityOK || health.Version != starrtest.Version {
+		t.Errorf("an instance without a timeout should use the default: %+v", health)
+	}
+
+	down := AppConfig{App: starr.Radarr.String(), Name: "down", URL: "http://127.0.0.1:1/", Key: starrtest.APIKey,
+		Timeout: time.Second}
+	if health := test.Health(&down); health.Severity != SeverityError || len(health.Errors) != 1 {
+		t.Errorf("an unreachable instance should have one error: %+v", health)
+	}
+}
+
+func TestHealthAllSeverity(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	healthy := starrtest.New(starr.Sonarr)
+	defer healthy.Close()
+
+	warning := starrtest.New(starr.Sonarr)
+	defer warning.Close()
+
+	warning.Seed("health", starrtest.Item{"type": SeverityWarning})
+
+	notice := starrtest.New(starr.Radarr)
+	defer notice.Close()
+
+	notice.Seed("update", starrtest.Item{"version": "9.9.9", "latest": true, "installable": true})
+
+	down := AppConfig{App: starr.Radarr.String(), Name: "down", URL: "http://127.0.0.1:1/", Key: starrtest.APIKey,
+		Timeout: time.Second}
+	second := newConfig(warning)
+	second.Name = "second"
+
+	tests := []struct {
+		desc      string
+		instances Instances
+		severity  string
+		counts    map[string]int
+	}{
+		{desc: "no instances", instances: Instances{}, severity: SeverityOK, counts: map[string]int{}},
+		{
+			desc:      "notice and ok",
+			instances: Instances{starr.Sonarr.String(): {*newConfig(healthy)}, starr.Radarr.String(): {*newConfig(notice)}},
+			severity:  SeverityNotice,
+			counts:    map[string]int{SeverityOK: 1, SeverityNotice: 1},
+		},
+		{
+			desc:      "warning beats notice",
+			instances: Instances{starr.Sonarr.String(): {*second}, starr.Radarr.String(): {*newConfig(notice)}},
+			severity:  SeverityWarning,
+			counts:    map[string]int{SeverityWarning: 1, SeverityNotice: 1},
+		},
+		{
+			desc: "error beats everything",
+			instances: Instances{
+				starr.Sonarr.String(): {*newConfig(healthy), *second},
+				starr.Radarr.String(): {*newConfig(notice), down},
+			},
+			severity: SeverityError,
+			counts:   map[string]int{SeverityOK: 1, SeverityNotice: 1, SeverityWarning: 1, SeverityError: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		matrix := test.HealthAll(tt.instances)
+		if matrix.Severity != tt.severity {
+			t.Errorf("%s: wrong severity: %s, expected %s", tt.desc, matrix.Severity, tt.severity)
+		}
+
+		for _, severity := range []string{SeverityOK, SeverityNotice, SeverityWarning, SeverityError} {
+			if matrix.Counts[severity] != tt.counts[severity] {
+				t.Errorf("%s: wrong %s count: %d, expected %d", tt.desc, severity, matrix.Counts[severity], tt.counts[severity])
+			}
+		}
+	}
+
+	matrix := test.HealthAll(Instances{starr.Sonarr.String(): {*second, *newConfig(healthy)}})
+	if list := matrix.Instances[starr.Sonarr.String()]; len(list) != 2 || list[0].Name != second.Name {
+		t.Errorf("instances should be sorted by name: %+v", list)
+	}
+}
@@ -2,13 +2,13 @@ package starrs
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/Notifiarr/toolbarr/pkg/mnd"
 	"github.com/mitchellh/go-homedir"
-	wr "github.com/wailsapp/wails/v2/pkg/runtime"
 	"golift.io/starr/lidarr"
 	"golift.io/starr/prowlarr"
 	"golift.io/starr/radarr"
@@ -105,21 +105,18 @@ func filterListItemsByID[N any](items []*N, selected Selected) []*N { //nolint:f
 // exportItems saves things like import lists, download clients and indexers to a json file.
 func (s *Starrs) exportItems(item string, config *AppConfig, data any, count int, err error) (string, error) {
 	if err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return "", fmt.Errorf(s.log.Translate("Getting %s from %s: %v", item, config.Name, err))
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Getting %s from %s: %v", item, config.Name, err))
 	}
 
-	filePath, err := wr.SaveFileDialog(s.ctx, wr.SaveDialogOptions{
-		DefaultDirectory:           lastPickedDir,
-		DefaultFilename:            fmt.Sprintf("%d%s%s.json", count, config.App, item),
-		Title:                      s.log.Translate("Save %d %s %s", count, config.App, item),
-		ShowHiddenFiles:            false,
-		CanCreateDirectories:       true,
-		TreatPackagesAsDirectories: false,
+	filePath, err := s.app.SaveFile(&mnd.FileDialog{
+		Directory: lastPickedDir,
+		Filename:  fmt.Sprintf("%d%s%s.json", count, config.App, item),
+		Title:     s.log.Translate("Save %d %s %s", count, config.App, item),
 	})
 	if err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return "", fmt.Errorf(s.log.Translate("Opening file browser: %v", err))
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Opening file browser: %v", err))
 	} else if filePath == "" {
 		return "", nil
 	}
@@ -128,8 +125,8 @@ func (s *Starrs) exportItems(item string, config *AppConfig, data any, count int
 
 	fileOpen, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mnd.Mode0640)
 	if err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return "", fmt.Errorf(s.log.Translate("Opening file: %v", err))
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Opening file: %v", err))
 	}
 	defer fileOpen.Close()
 
@@ -137,8 +134,8 @@ func (s *Starrs) exportItems(item string, config *AppConfig, data any, count int
 	encoder.SetIndent("", "  ")
 
 	if err = encoder.Encode(data); err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return "", fmt.Errorf(s.log.Translate("Encoding and writing file: %v", err))
+		s.app.LogError(err.Error())
+		return "", errors.New(s.log.Translate("Encoding and writing file: %v", err))
 	}
 
 	return s.log.Translate("Saved %d %s to %s", count, item, filePath), nil
@@ -165,15 +162,15 @@ func getSavePath() string {
 func importItems[N any](s *Starrs, item string, config *AppConfig, input []N) (*DataReply, error) { //nolint:varnamelen,lll
 	s.log.Tracef("Call:Import%s%s()", config.App, item)
 
-	filePath, err := wr.OpenFileDialog(s.ctx, wr.OpenDialogOptions{
-		DefaultDirectory: lastPickedDir,
-		DefaultFilename:  fmt.Sprintf("%s%s.json", config.App, item),
-		Title:            s.log.Translate("Select Export File"),
-		Filters:          []wr.FileFilter{{DisplayName: "JSON (*.json)", Pattern: "*.json"}},
+	filePath, err := s.app.OpenFile(&mnd.FileDialog{
+		Directory: lastPickedDir,
+		Filename:  fmt.Sprintf("%s%s.json", config.App, item),
+		Title:     s.log.Translate("Select Export File"),
+		Filters:   mnd.JSONFilter(),
 	})
 	if err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return nil, fmt.Errorf(s.log.Translate("Opening file browser: %v", err))
+		s.app.LogError(err.Error())
+		return nil, errors.New(s.log.Translate("Opening file browser: %v", err))
 	} else if filePath == "" {
 		return &DataReply{Msg: ""}, nil
 	}
@@ -183,14 +180,14 @@ func importItems[N any](s *Starrs, item string, config *AppConfig, input []N) (*
 
 	fileOpen, err := os.Open(filePath)
 	if err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return nil, fmt.Errorf(s.log.Translate("Opening file: %v", err))
+		s.app.LogError(err.Error())
+		return nil, errors.New(s.log.Translate("Opening file: %v", err))
 	}
 	defer fileOpen.Close()
 
 	if err := json.NewDecoder(fileOpen).Decode(&input); err != nil {
-		wr.LogError(s.ctx, err.Error())
-		return nil, fmt.Errorf(s.log.Translate("Decoding input file failed: %v", err))
+		s.app.LogError(err.Error())
+		return nil, errors.New(s.log.Translate("Decoding input file failed: %v", err))
 	}
 
 	return &DataReply{
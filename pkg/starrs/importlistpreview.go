@@ -0,0 +1,181 @@
+package starrs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+)
+
+/* Preview what an import list adds, compared to the library and the exclusions. */
+
+// Import list preview item statuses.
+const (
+	ListNew       = "new"      // The list would add the item.
+	ListInLibrary = "library"  // The item is already in the library.
+	ListExcluded  = "excluded" // The item is excluded, so the list skips it.
+)
+
+// ListPreviewItem is one item an import list returns.
+type ListPreviewItem struct {
+	ForeignID string
+	Title     string
+	Year      int64
+	Status    string
+}
+
+// ListPreview is what an import list would add to the library.
+type ListPreview struct {
+	Msg       string
+	ListID    int64
+	Items     []*ListPreviewItem
+	New       int
+	InLibrary int
+	Excluded  int
+}
+
+// previewName is added to the name of the copy of a list that is synced for a preview.
+const previewName = " (" + mnd.Title + " preview)"
+
+// PreviewImportList fetches an import list, and returns its items marked as new, in the library or excluded.
+// Nothing is added, and the list does not need to be enabled: a copy of the list with automatic add turned
+// off is synced, read and deleted. Only Radarr keeps the items a list returns without adding them, so
+// Sonarr, Lidarr and Readarr lists cannot be previewed.
+func (s *Starrs) PreviewImportList(config *AppConfig, listID int64) (*ListPreview, error) {
+	s.log.Tracef("Call:PreviewImportList(%s, %s, %d)", config.App, config.Name, listID)
+
+	if starr.App(config.App) != starr.Radarr {
+		return nil, errors.New(s.log.Translate("%s cannot fetch an import list without adding its items. "+
+			"Only Radarr import lists can be previewed.", config.App))
+	} else if listID < 1 {
+		return nil, errors.New(s.log.Translate("Pick an import list to preview."))
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := instance.previewImportList(listID)
+	if err != nil {
+		msg := s.log.Translate("Previewing %s import list: %v", config.Name, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	preview.Msg = s.log.Translate("The list would add %d items. %d are in the library, and %d are excluded.",
+		preview.New, preview.InLibrary, preview.Excluded)
+
+	return preview, nil
+}
+
+// previewImportList syncs a copy of a list that cannot add anything, and returns what the copy found.
+func (i *instance) previewImportList(listID int64) (*ListPreview, error) {
+	client := radarr.New(i.Config)
+
+	// The starr library cannot get one radarr import list, so find it in the list of them.
+	lists, err := client.GetImportListsContext(i.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := slices.IndexFunc(lists, func(list *radarr.ImportListOutput) bool { return list.ID == listID })
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: import list %d", ErrNotFound, listID)
+	}
+
+	preview := &radarr.ImportListInput{}
+	if err := convert(lists[idx], preview); err != nil {
+		return nil, err
+	}
+
+	preview.ID, preview.Name = 0, lists[idx].Name+previewName
+	preview.Enabled, preview.EnableAuto, preview.SearchOnAdd = true, false, false
+
+	created, err := client.AddImportListContext(i.ctx, preview)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := client.DeleteImportListContext(i.ctx, []int64{created.ID}); err != nil {
+			i.log.Errorf("Deleting import list preview %s from %s: %v", preview.Name, i.config.Name, err)
+		}
+	}()
+
+	if _, err := i.runCommand(map[string]any{"name": "ImportListSync", "definitionId": created.ID}); err != nil {
+		return nil, err
+	}
+
+	result, err := i.listItems(created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ListID = listID
+
+	return result, nil
+}
+
+// listItems returns the items Radarr found the last time a list synced, marked as new, in the library or excluded.
+func (i *instance) listItems(listID int64) (*ListPreview, error) {
+	lib, api := getLibrary(i.config.App), getExclusionAPI(i.config.App)
+
+	// The starr library has no call for the movies an import list found.
+	list := []LibraryItem{}
+	if err := i.getInto(i.ctx, "importlist/movie", &list); err != nil {
+		return nil, err
+	}
+
+	items, err := i.library()
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions, err := i.exclusionEntries(api)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make(map[string]bool, len(items))
+	for _, item := range items {
+		have[item.String(lib.Foreign)] = true
+	}
+
+	excluded := make(map[string]bool, len(exclusions))
+	for _, entry := range exclusions {
+		excluded[entry.ForeignID] = true
+	}
+
+	preview := &ListPreview{ListID: listID, Items: []*ListPreviewItem{}}
+	seen := make(map[string]bool, len(list))
+
+	for _, item := range list {
+		foreign := item.String(lib.Foreign)
+		if seen[foreign] || !slices.Contains(item.IDs("lists"), listID) {
+			continue
+		}
+
+		seen[foreign] = true
+		entry := &ListPreviewItem{ForeignID: foreign, Title: item.String(lib.Title), Year: item.Int("year")}
+		preview.Items = append(preview.Items, entry)
+
+		switch {
+		case have[foreign]:
+			entry.Status = ListInLibrary
+			preview.InLibrary++
+		case excluded[foreign]:
+			entry.Status = ListExcluded
+			preview.Excluded++
+		default:
+			entry.Status = ListNew
+			preview.New++
+		}
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,71 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestPreviewImportList(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	server.Seed("importlist", starrtest.Item{"id": 7, "name": "Trending", "enabled": false, "enableAuto": true})
+	// Another list found these before.
+	server.Seed("importlist/movie",
+		starrtest.Item{"tmdbId": 4, "title": "Other List", "lists": []int64{8}},
+		starrtest.Item{"tmdbId": 1, "title": "New", "year": 2001, "lists": []int64{8}},
+	)
+	// The preview list finds these when it syncs.
+	server.SeedOnCommand("ImportListSync", "importlist/movie",
+		starrtest.Item{"tmdbId": 1, "title": "New", "year": 2001},
+		starrtest.Item{"tmdbId": 2, "title": "Have"},
+		starrtest.Item{"tmdbId": 3, "title": "Excluded"},
+		starrtest.Item{"tmdbId": 1, "title": "New", "year": 2001},
+	)
+	server.Seed("movie", starrtest.Item{"id": 20, "tmdbId": 2, "title": "Have"})
+	server.Seed("exclusions", starrtest.Item{"id": 30, "tmdbId": 3, "movieTitle": "Excluded"})
+	config := newConfig(server)
+
+	preview, err := test.PreviewImportList(config, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if preview.ListID != 7 || preview.New != 1 || preview.InLibrary != 1 || preview.Excluded != 1 ||
+		len(preview.Items) != 3 {
+		t.Errorf("wrong preview for list 7: %+v", preview)
+	}
+
+	if preview.Items[0].Status != ListNew || preview.Items[0].Year != 2001 {
+		t.Errorf("wrong first item: %+v", preview.Items[0])
+	}
+
+	// The list stays disabled, the copy that synced is deleted, and nothing is added.
+	if lists := server.Items("importlist"); len(lists) != 1 || lists[0]["enabled"] != false {
+		t.Errorf("the import lists changed: %v", lists)
+	}
+
+	if movies := server.Items("movie"); len(movies) != 1 {
+		t.Errorf("the preview added movies: %v", movies)
+	}
+
+	if _, err := test.PreviewImportList(config, 0); err == nil {
+		t.Error("a list must be picked")
+	}
+
+	if _, err := test.PreviewImportList(config, 9); err == nil {
+		t.Error("list 9 does not exist")
+	}
+
+	sonarr := *config
+	sonarr.App = starr.Sonarr.String()
+
+	if _, err := test.PreviewImportList(&sonarr, 7); err == nil {
+		t.Error("sonarr has no import list preview")
+	}
+}
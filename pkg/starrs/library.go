@@ -0,0 +1,353 @@
+package starrs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/sonarr"
+)
+
+/* Library items (movies, series, artists and authors) from the starr app APIs. */
+
+// ErrNotFound is returned when a lookup has no results.
+var ErrNotFound = errors.New("no results found")
+
+// LibraryItem is a movie, series, artist or author. Data from the starr library is kept as
+// json objects, so the code that works the same way for every app can read any of them.
+type LibraryItem map[string]any
+
+// Profile kinds in library.Profiles.
+const (
+	qualityProfile  = "qualityprofile"
+	metadataProfile = "metadataprofile"
+)
+
+// library describes an app's main item API.
+type library struct {
+	Path     string            // API path: movie, series, artist, author.
+	IDs      string            // Editor field with the item ids: movieIds.
+	Foreign  string            // Field with the metadata source id: tmdbId.
+	Title    string            // Field with the item's name.
+	Search   string            // Add option to search for the item after adding it.
+	Profiles map[string]string // Item field => profile kind. Profiles are matched by name.
+}
+
+// getLibrary returns the item API for an app. Returns nil for Prowlarr.
+func getLibrary(app string) *library {
+	switch starr.App(app) {
+	case starr.Lidarr:
+		return &library{
+			Path: "artist", IDs: "artistIds", Foreign: "foreignArtistId", Title: "artistName",
+			Search: "searchForMissingAlbums",
+			Profiles: map[string]string{
+				"qualityProfileId": qualityProfile, "metadataProfileId": metadataProfile,
+			},
+		}
+	case starr.Radarr:
+		return &library{
+			Path: "movie", IDs: "movieIds", Foreign: "tmdbId", Title: "title",
+			Search: "searchForMovie", Profiles: map[string]string{"qualityProfileId": qualityProfile},
+		}
+	case starr.Readarr:
+		return &library{
+			Path: "author", IDs: "authorIds", Foreign: "foreignAuthorId", Title: "authorName",
+			Search: "searchForMissingBooks",
+			Profiles: map[string]string{
+				"qualityProfileId": qualityProfile, "metadataProfileId": metadataProfile,
+			},
+		}
+	case starr.Sonarr, starr.Whisparr:
+		return &library{
+			Path: "series", IDs: "seriesIds", Foreign: "tvdbId", Title: "title",
+			Search: "searchForMissingEpisodes", Profiles: map[string]string{"qualityProfileId": qualityProfile},
+		}
+	default:
+		return nil
+	}
+}
+
+// ID returns the item's id.
+func (l LibraryItem) ID() int64 {
+	return l.Int("id")
+}
+
+// Path returns the item's folder.
+func (l LibraryItem) Path() string {
+	path, _ := l["path"].(string)
+	return path
+}
+
+// String returns a string field.
+func (l LibraryItem) String(field string) string {
+	switch val := l[field].(type) {
+	case string:
+		return val
+	case float64: // Large ids would print with an exponent.
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// Int returns a number field. JSON numbers decode as float64.
+func (l LibraryItem) Int(field string) int64 {
+	switch val := l[field].(type) {
+	case float64:
+		return int64(val)
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	default:
+		return 0
+	}
+}
+
+// Tags returns the item's tag ids.
+func (l LibraryItem) Tags() []int64 {
+	return l.IDs("tags")
+}
+
+// IDs returns a field with a list of ids.
+func (l LibraryItem) IDs(field string) []int64 {
+	list, _ := l[field].([]any)
+	ids := make([]int64, 0, len(list))
+
+	for _, id := range list {
+		if id, ok := id.(float64); ok {
+			ids = append(ids, int64(id))
+		}
+	}
+
+	return ids
+}
+
+// copy returns a shallow copy of the item.
+func (l LibraryItem) copy() LibraryItem {
+	item := make(LibraryItem, len(l))
+	for k, v := range l {
+		item[k] = v
+	}
+
+	return item
+}
+
+// toItems converts a list from the starr library to library items.
+func toItems(list any) ([]LibraryItem, error) {
+	items := []LibraryItem{}
+	if err := convert(list, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// convert copies data between the starr library's types and library items, through json.
+func convert(input, output any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// library returns the items in an app's library.
+func (i *instance) library() ([]LibraryItem, error) {
+	var (
+		list any
+		err  error
+	)
+
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		list, err = lidarr.New(i.Config).GetArtistContext(i.ctx, "")
+	case starr.Radarr:
+		list, err = radarr.New(i.Config).GetMovieContext(i.ctx, &radarr.GetMovie{})
+	case starr.Readarr:
+		// The starr library can only get one author at a time.
+		list = &[]LibraryItem{}
+		err = i.getInto(i.ctx, "author", list)
+	case starr.Sonarr, starr.Whisparr:
+		list, err = sonarr.New(i.Config).GetAllSeriesContext(i.ctx)
+	default:
+		return nil, fmt.Errorf("%w: %s has no library", ErrInvalidApp, i.config.App)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toItems(list)
+}
+
+// addItem adds a movie, series, artist or author to an app's library, and returns its id.
+func (i *instance) addItem(item LibraryItem) (int64, error) {
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		artist := &lidarr.Artist{}
+		if err := convert(item, artist); err != nil {
+			return 0, err
+		}
+
+		added, err := lidarr.New(i.Config).AddArtistContext(i.ctx, artist)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	case starr.Radarr, starr.Readarr:
+		// The starr library's movie input has no path, so the movie would not keep its folder,
+		// and it cannot add authors.
+		added := LibraryItem{}
+		err := i.postInto(i.ctx, getLibrary(i.config.App).Path, item, &added)
+
+		return added.ID(), err
+	case starr.Sonarr, starr.Whisparr:
+		series := &sonarr.AddSeriesInput{}
+		if err := convert(item, series); err != nil {
+			return 0, err
+		}
+
+		added, err := sonarr.New(i.Config).AddSeriesContext(i.ctx, series)
+		if err != nil {
+			return 0, err
+		}
+
+		return added.ID, nil
+	default:
+		return 0, fmt.Errorf("%w: %s has no library", ErrInvalidApp, i.config.App)
+	}
+}
+
+// profiles returns an app's quality or metadata profiles. Kind is a value in library.Profiles.
+func (i *instance) profiles(kind string) ([]LibraryItem, error) {
+	var (
+		list any
+		err  error
+	)
+
+	if kind == metadataProfile {
+		list, err = i.metadataProfiles(i.config)
+	} else {
+		list, err = i.qualityProfiles(i.config)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toItems(list)
+}
+
+// rootPaths returns the paths of an app's root folders.
+func (i *instance) rootPaths() ([]string, error) {
+	list, err := i.rootFolders(i.config)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := toItems(list)
+	paths := make([]string, len(roots))
+
+	for idx, root := range roots {
+		paths[idx] = root.Path()
+	}
+
+	return paths, err
+}
+
+// names returns id => name and name => id maps for a list of profiles.
+// Names are lowercased in the name => id map.
+func names(list []LibraryItem, field string) (map[int64]string, map[string]int64) {
+	names := make(map[int64]string, len(list))
+	ids := make(map[string]int64, len(list))
+
+	for _, item := range list {
+		names[item.ID()] = item.String(field)
+		ids[strings.ToLower(item.String(field))] = item.ID()
+	}
+
+	return names, ids
+}
+
+// lookup searches the app's metadata source, and returns the first result.
+func (i *instance) lookup(term string) (LibraryItem, error) {
+	var (
+		list any
+		err  error
+	)
+
+	switch starr.App(i.config.App) {
+	case starr.Radarr:
+		list, err = radarr.New(i.Config).LookupContext(i.ctx, term)
+	case starr.Sonarr, starr.Whisparr:
+		list, err = sonarr.New(i.Config).LookupContext(i.ctx, term)
+	default:
+		// The starr library's lidarr and readarr lookups find albums and books, not artists and authors.
+		lib := getLibrary(i.config.App)
+		req := starr.Request{URI: path.Join(apiVersion(i.config.App), lib.Path, "lookup"), Query: url.Values{"term": {term}}}
+
+		if err = i.GetInto(i.ctx, req, &list); err != nil {
+			err = fmt.Errorf("api.Get(%s): %w", &req, err)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := toItems(list)
+	if err != nil {
+		return nil, err
+	} else if len(items) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, term)
+	}
+
+	return items[0], nil
+}
+
+// mapPath moves a path from one root folder to another, the same way the migrator does.
+// Returns the new path and root folder, or false if the path is not in a mapped root folder.
+func mapPath(path string, folders map[string]string) (string, string, bool) {
+	roots := make([]string, 0, len(folders))
+	for root := range folders {
+		roots = append(roots, root)
+	}
+
+	dirs, found := itemInRoot(path, roots)
+	if !found {
+		return "", "", false
+	}
+
+	from := longestRoot(dirs)
+
+	return trailingSlash(folders[from]) + strings.TrimPrefix(path, trailingSlash(from)), folders[from], true
+}
+
+// longestRoot returns the longest root folder from a list of matches,
+// so /data/tv4k/ wins over /data/tv/ for /data/tv4k/show.
+func longestRoot(dirs []string) string {
+	root := dirs[0]
+	for _, dir := range dirs {
+		if len(dir) > len(root) {
+			root = dir
+		}
+	}
+
+	return root
+}
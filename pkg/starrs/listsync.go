@@ -0,0 +1,450 @@
+package starrs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/sonarr"
+)
+
+/* Sync import lists on demand, and keep a history of what each sync added. */
+
+const (
+	// syncTimeout is how long to wait for an import list sync to finish.
+	syncTimeout = 10 * time.Minute
+	// historyLimit is how many syncs the history file keeps. The oldest are removed first.
+	historyLimit = 2000
+)
+
+// ErrSyncTimeout is returned when an import list sync does not finish in time.
+var ErrSyncTimeout = errors.New("timed out waiting for the import list sync")
+
+// syncCounts finds the counts in the message Sonarr, Lidarr and Readarr put on a finished import list sync.
+// ie. "Import List Sync Completed. Items found: 12, Series added: 3".
+var syncCounts = regexp.MustCompile(`Items found: (\d+), \w+ added: (\d+)`) //nolint:gochecknoglobals
+
+// ListSync is the result of syncing one import list.
+type ListSync struct {
+	App      string
+	Instance string
+	ListID   int64
+	ListName string
+	Added    int  // Library items added by the sync, from this list.
+	Listed   int  // Items the list returned.
+	Excluded int  // Items skipped because they're excluded. Radarr only.
+	Skipped  int  // Items the list returned that were not added for another reason.
+	Unknown  bool // The sync finished, but what it added could not be counted.
+	Failed   bool
+	Msg      string
+	Date     time.Time
+	Elapsed  string
+}
+
+// ListSyncTotal sums the history for one import list.
+type ListSyncTotal struct {
+	App       string
+	Instance  string
+	ListID    int64
+	ListName  string
+	Syncs     int
+	Unknown   int // Syncs that could not be counted. They are not in Added.
+	Added     int
+	Excluded  int
+	LastSync  time.Time
+	LastAdded time.Time // Zero if the list has never added anything.
+}
+
+// SyncImportLists syncs an import list and waits for it to finish. A listID of 0 syncs every
+// list with automatic add enabled, one at a time, so each list gets its own result.
+// Emits ListSyncProgress after each list.
+func (s *Starrs) SyncImportLists(config *AppConfig, listID int64) ([]*ListSync, error) {
+	s.log.Tracef("Call:SyncImportLists(%s, %s, %d)", config.App, config.Name, listID)
+
+	results, err := s.syncImportLists(config, listID)
+	if err != nil {
+		msg := s.log.Translate("Syncing %s import lists: %v", config.Name, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return results, nil
+}
+
+// SyncImportListsAll syncs every import list in every provided instance. Instances sync
+// concurrently; lists in one instance sync one at a time. Failures are in the results.
+func (s *Starrs) SyncImportListsAll(instances Instances) []*ListSync {
+	s.log.Tracef("Call:SyncImportListsAll(%d)", len(instances))
+
+	input := make(chan *AppConfig)
+	output := make(chan []*ListSync)
+	wait := sync.WaitGroup{}
+
+	for range healthWorkers {
+		wait.Add(1)
+
+		go func() {
+			defer s.log.CapturePanic()
+			defer wait.Done()
+
+			for config := range input {
+				results, err := s.syncImportLists(config, 0)
+				if err != nil {
+					results = []*ListSync{{
+						App: config.App, Instance: config.Name, Failed: true, Msg: err.Error(), Date: time.Now(),
+					}}
+				}
+
+				output <- results
+			}
+		}()
+	}
+
+	go func() {
+		for app := range instances {
+			for idx := range instances[app] {
+				if getLibrary(instances[app][idx].App) != nil {
+					input <- &instances[app][idx]
+				}
+			}
+		}
+
+		close(input)
+		wait.Wait()
+		close(output)
+	}()
+
+	all := []*ListSync{}
+	for results := range output {
+		all = append(all, results...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Instance != all[j].Instance {
+			return all[i].Instance < all[j].Instance
+		}
+
+		return all[i].ListID < all[j].ListID
+	})
+
+	return all
+}
+
+func (s *Starrs) syncImportLists(config *AppConfig, listID int64) ([]*ListSync, error) {
+	if getLibrary(config.App) == nil {
+		return nil, fmt.Errorf("%w: %s has no import lists that add items", ErrInvalidApp, config.App)
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := instance.importList(config)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := toItems(list)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*ListSync{}
+
+	for _, list := range lists {
+		if (listID == 0 && listEnabled(list)) || list.ID() == listID {
+			result := instance.syncImportList(list)
+			results = append(results, result)
+			s.app.Emit("ListSyncProgress", result)
+		}
+	}
+
+	if listID != 0 && len(results) == 0 {
+		return nil, fmt.Errorf("%w: import list %d", ErrNotFound, listID)
+	}
+
+	return results, nil
+}
+
+// listEnabled returns false if an import list cannot add items.
+// Radarr has an enabled field; the other apps have enableAutomaticAdd.
+func listEnabled(list LibraryItem) bool {
+	for _, field := range []string{"enabled", "enableAutomaticAdd"} {
+		if enabled, ok := list[field].(bool); ok && !enabled {
+			return false
+		}
+	}
+
+	return true
+}
+
+// syncImportList runs an import list sync command for one list, waits for it, and counts what it added.
+func (i *instance) syncImportList(list LibraryItem) *ListSync {
+	result := &ListSync{
+		App:      i.config.App,
+		Instance: i.config.Name,
+		ListID:   list.ID(),
+		ListName: list.String("name"),
+		Date:     time.Now(),
+	}
+	defer func() { result.Elapsed = time.Since(result.Date).Round(time.Millisecond).String() }()
+
+	if err := i.syncAndCount(result); err != nil {
+		result.Failed, result.Msg = true, err.Error()
+		return result
+	}
+
+	if result.Unknown {
+		result.Msg = i.log.Translate("Import list %s synced, but what it added could not be counted.", result.ListName)
+	} else {
+		result.Msg = i.log.Translate("Import list %s added %d items.", result.ListName, result.Added)
+	}
+
+	return result
+}
+
+// syncAndCount runs the sync and counts what it added. Other lists, and people, add to the library too,
+// so Radarr only counts new movies this list returned. The other apps do not keep the items a list
+// returns, so their counts come from the message on the finished command.
+func (i *instance) syncAndCount(result *ListSync) error {
+	if starr.App(i.config.App) != starr.Radarr {
+		command, err := i.runCommand(map[string]any{"name": "ImportListSync", "definitionId": result.ListID})
+		if err != nil {
+			return err
+		}
+
+		counts := syncCounts.FindStringSubmatch(command.String("message"))
+		if counts == nil {
+			result.Unknown = true
+			return nil
+		}
+
+		result.Listed, _ = strconv.Atoi(counts[1])
+		result.Added, _ = strconv.Atoi(counts[2])
+		result.Skipped = max(result.Listed-result.Added, 0)
+
+		return nil
+	}
+
+	lib := getLibrary(i.config.App)
+
+	before, err := i.library()
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool, len(before))
+	for _, item := range before {
+		have[item.String(lib.Foreign)] = true
+	}
+
+	if _, err := i.runCommand(map[string]any{"name": "ImportListSync", "definitionId": result.ListID}); err != nil {
+		return err
+	}
+
+	after, err := i.library()
+	if err != nil {
+		return err
+	}
+
+	listed, err := i.listItems(result.ListID)
+	if err != nil {
+		return err
+	}
+
+	fromList := make(map[string]bool, len(listed.Items))
+	for _, item := range listed.Items {
+		fromList[item.ForeignID] = true
+	}
+
+	for _, item := range after {
+		if foreign := item.String(lib.Foreign); !have[foreign] && fromList[foreign] {
+			result.Added++
+		}
+	}
+
+	result.Listed, result.Excluded = len(listed.Items), listed.Excluded
+	result.Skipped = max(result.Listed-result.Added-result.Excluded, 0)
+
+	return nil
+}
+
+// runCommand starts an app command, waits for it to finish, and returns the finished command.
+func (i *instance) runCommand(command map[string]any) (LibraryItem, error) {
+	// The starr library's command requests have no field for the import list id.
+	started := LibraryItem{}
+	if err := i.postInto(i.ctx, "command", command, &started); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(syncTimeout)
+	ticker := time.NewTicker(waitTime)
+
+	defer timeout.Stop()
+	defer ticker.Stop()
+
+	for status := started; ; {
+		switch status.String("status") {
+		case "completed":
+			return status, nil
+		case "failed", "aborted", "cancelled", "orphaned":
+			return nil, fmt.Errorf("%w: command %s: %s",
+				starr.ErrRequestError, status.String("status"), status.String("message"))
+		}
+
+		select {
+		case <-timeout.C:
+			return nil, ErrSyncTimeout
+		case <-i.ctx.Done():
+			return nil, i.ctx.Err()
+		case <-ticker.C:
+		}
+
+		var err error
+		if status, err = i.commandStatus(started.ID()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// commandStatus returns an app command, with its status and message.
+func (i *instance) commandStatus(commandID int64) (LibraryItem, error) {
+	var (
+		command any
+		err     error
+	)
+
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		command, err = lidarr.New(i.Config).GetCommandStatusContext(i.ctx, commandID)
+	case starr.Sonarr, starr.Whisparr:
+		command, err = sonarr.New(i.Config).GetCommandStatusContext(i.ctx, commandID)
+	default:
+		// The starr library cannot get one radarr or readarr command.
+		command = &LibraryItem{}
+		err = i.getInto(i.ctx, "command/"+strconv.FormatInt(commandID, 10), command)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := LibraryItem{}
+	if err := convert(command, &status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// ReadListHistory reads the import list sync history file. A missing file is an empty history.
+func ReadListHistory(path string) ([]*ListSync, error) {
+	history := []*ListSync{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading list history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("decoding list history: %w", err)
+	}
+
+	return history, nil
+}
+
+// AddListHistory adds sync results to the history file, and removes the oldest entries past the limit.
+func AddListHistory(path string, results []*ListSync) ([]*ListSync, error) {
+	history, err := ReadListHistory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	history = append(history, results...)
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+
+	data, err := json.MarshalIndent(history, "", " ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding list history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), mnd.Mode0750); err != nil {
+		return nil, fmt.Errorf("creating list history folder: %w", err)
+	}
+
+	if err := mnd.WriteFile(path, data); err != nil {
+		return nil, fmt.Errorf("writing list history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ListHistoryTotals sums the history for each import list, so lists that never add anything stand out.
+// Instances in different apps may have the same name. Sorted by app, instance and list id.
+func ListHistoryTotals(history []*ListSync) []*ListSyncTotal {
+	totals := make(map[string]*ListSyncTotal)
+
+	for _, item := range history {
+		if item.ListID == 0 {
+			continue // An instance that could not be reached.
+		}
+
+		key := item.App + "\x00" + item.Instance + "\x00" + strconv.FormatInt(item.ListID, 10)
+		if totals[key] == nil {
+			totals[key] = &ListSyncTotal{App: item.App, Instance: item.Instance, ListID: item.ListID}
+		}
+
+		total := totals[key]
+		total.ListName = item.ListName
+		total.Syncs++
+		total.Added += item.Added
+		total.Excluded += item.Excluded
+
+		if item.Unknown {
+			total.Unknown++
+		}
+
+		if item.Date.After(total.LastSync) {
+			total.LastSync = item.Date
+		}
+
+		if item.Added > 0 && item.Date.After(total.LastAdded) {
+			total.LastAdded = item.Date
+		}
+	}
+
+	list := make([]*ListSyncTotal, 0, len(totals))
+	for _, total := range totals {
+		list = append(list, total)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].App != list[j].App {
+			return list[i].App < list[j].App
+		}
+
+		if list[i].Instance != list[j].Instance {
+			return list[i].Instance < list[j].Instance
+		}
+
+		return list[i].ListID < list[j].ListID
+	})
+
+	return list
+}
@@ -0,0 +1,170 @@
+package starrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestSyncImportLists(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	server.Seed("importlist",
+		starrtest.Item{"id": 7, "name": "Trending", "enabled": true},
+		starrtest.Item{"id": 8, "name": "Disabled", "enabled": false},
+	)
+	server.Seed("importlist/movie",
+		starrtest.Item{"tmdbId": 1, "title": "New", "lists": []int64{7}},
+		starrtest.Item{"tmdbId": 2, "title": "Have", "lists": []int64{7}},
+		starrtest.Item{"tmdbId": 3, "title": "Excluded", "lists": []int64{7}},
+		starrtest.Item{"tmdbId": 4, "title": "Not Available", "lists": []int64{7}},
+	)
+	server.Seed("movie", starrtest.Item{"id": 20, "tmdbId": 2, "title": "Have"})
+	server.Seed("exclusions", starrtest.Item{"id": 30, "tmdbId": 3, "movieTitle": "Excluded"})
+	// Another list, or a person, added movie 9 while the list synced. It's not counted.
+	server.SeedOnCommand("ImportListSync", "movie",
+		starrtest.Item{"tmdbId": 1, "title": "New"}, starrtest.Item{"tmdbId": 9, "title": "Someone Else"})
+	config := newConfig(server)
+
+	results, err := test.SyncImportLists(config, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].ListID != 7 || results[0].Failed {
+		t.Fatalf("only the enabled list should sync: %+v", results)
+	}
+
+	if sync := results[0]; sync.Added != 1 || sync.Listed != 4 || sync.Excluded != 1 || sync.Skipped != 2 {
+		t.Errorf("wrong counts: %+v", sync)
+	}
+
+	if !server.Called("POST /api/v3/command") {
+		t.Error("the sync command was not sent")
+	}
+
+	// A disabled list syncs when it's picked, and adds nothing the second time.
+	if results, err = test.SyncImportLists(config, 8); err != nil || len(results) != 1 || results[0].Added != 0 {
+		t.Errorf("wrong result for list 8: %+v, %v", results, err)
+	}
+
+	if _, err := test.SyncImportLists(config, 9); err == nil {
+		t.Error("list 9 does not exist")
+	}
+}
+
+func TestSyncImportListsAll(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	instances := make(Instances)
+
+	for _, app := range []starr.App{starr.Sonarr, starr.Lidarr, starr.Prowlarr} {
+		server := starrtest.New(app)
+		defer server.Close()
+
+		server.Seed("importlist",
+			starrtest.Item{"id": 1, "name": "Popular", "enableAutomaticAdd": true},
+			starrtest.Item{"id": 2, "name": "Manual", "enableAutomaticAdd": false},
+		)
+		instances[app.String()] = []AppConfig{*newConfig(server)}
+
+		if app == starr.Sonarr {
+			server.CommandMessage("ImportListSync", "Import List Sync Completed. Items found: 5, Series added: 2")
+		}
+	}
+
+	sonarr := instances[starr.Sonarr.String()][0]
+	sonarr.URL = "http://127.0.0.1:1"
+	instances["Down"] = []AppConfig{sonarr}
+	instances["Down"][0].Name = "down"
+
+	results := test.SyncImportListsAll(instances)
+	if len(results) != 3 {
+		t.Fatalf("expected a list for sonarr and lidarr, and a failure for down: %+v", results)
+	}
+
+	if !results[0].Failed || results[0].Instance != "down" {
+		t.Errorf("down instance should fail: %+v", results[0])
+	}
+
+	if results[1].Failed || results[1].ListID != 1 || results[2].ListID != 1 {
+		t.Errorf("wrong results: %+v, %+v", results[1], results[2])
+	}
+
+	// Sonarr's counts come from the command message. Lidarr's message has none.
+	for _, result := range results[1:] {
+		switch result.App {
+		case starr.Sonarr.String():
+			if result.Unknown || result.Listed != 5 || result.Added != 2 || result.Skipped != 3 {
+				t.Errorf("wrong sonarr counts: %+v", result)
+			}
+		case starr.Lidarr.String():
+			if !result.Unknown || result.Added != 0 {
+				t.Errorf("lidarr counts should be unknown: %+v", result)
+			}
+		}
+	}
+}
+
+func TestListHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history", "listsync.json")
+	now := time.Now().Round(0)
+
+	if history, err := ReadListHistory(path); err != nil || len(history) != 0 {
+		t.Fatalf("a missing file is an empty history: %v, %v", history, err)
+	}
+
+	_, err := AddListHistory(path, []*ListSync{
+		{App: "Radarr", Instance: "radarr", ListID: 7, ListName: "Trending", Added: 3, Date: now.Add(-time.Hour)},
+		{App: "Radarr", Instance: "radarr", ListID: 8, ListName: "Dead", Date: now.Add(-time.Hour)},
+		{Instance: "down", Failed: true, Date: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := AddListHistory(path, []*ListSync{
+		{App: "Radarr", Instance: "radarr", ListID: 7, ListName: "Trending", Excluded: 2, Date: now},
+		{App: "Radarr", Instance: "radarr", ListID: 8, ListName: "Dead", Date: now},
+		{App: "Radarr", Instance: "radarr", ListID: 8, ListName: "Dead", Unknown: true, Date: now},
+		{App: "Sonarr", Instance: "radarr", ListID: 7, ListName: "Same name", Added: 1, Date: now},
+	})
+	if err != nil || len(history) != 7 {
+		t.Fatalf("wrong history: %d, %v", len(history), err)
+	}
+
+	// The history is written to a temp file and renamed, so nothing is left behind.
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("only the history file should be in the folder: %v", files)
+	}
+
+	totals := ListHistoryTotals(history)
+	if len(totals) != 3 {
+		t.Fatalf("expected totals for 3 lists: %+v", totals)
+	}
+
+	if total := totals[0]; total.ListID != 7 || total.Syncs != 2 || total.Added != 3 || total.Excluded != 2 ||
+		!total.LastSync.Equal(now) || !total.LastAdded.Equal(now.Add(-time.Hour)) {
+		t.Errorf("wrong totals for list 7: %+v", total)
+	}
+
+	if total := totals[1]; total.Syncs != 3 || total.Unknown != 1 || total.Added != 0 || !total.LastAdded.IsZero() {
+		t.Errorf("list 8 never added anything: %+v", total)
+	}
+
+	// An instance with the same name in another app has its own lists.
+	if total := totals[2]; total.App != "Sonarr" || total.ListID != 7 || total.Syncs != 1 || total.Added != 1 {
+		t.Errorf("wrong totals for the sonarr list: %+v", total)
+	}
+}
@@ -0,0 +1,333 @@
+package starrs
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+/* Copy library items from one starr instance to another through their APIs. */
+
+// Merge item statuses.
+const (
+	MergeAdd    = "add"    // Preview: the item will be added.
+	MergeExists = "exists" // The target already has the item.
+	MergeSkip   = "skip"   // The item cannot be added. Msg says why.
+	MergeAdded  = "added"  // Merge: the item was added.
+	MergeFailed = "failed" // Merge: adding the item failed. Msg has the error.
+)
+
+// MergeInput selects items to copy from a source instance to a target instance.
+type MergeInput struct {
+	Selected Selected          // Source item ids to copy. Copies every item when empty.
+	Folders  map[string]string // Source root folder => target root folder. Unmapped items keep their path.
+	Search   bool              // Search for the items after adding them.
+}
+
+// MergeItem is the plan, and then the result, for one item.
+type MergeItem struct {
+	SourceID int64
+	TargetID int64
+	Title    string
+	Path     string // Path in the source.
+	NewPath  string // Path in the target.
+	Status   string
+	Msg      string
+}
+
+// MergePlan is the preview of a merge, or the result of one.
+type MergePlan struct {
+	Msg     string
+	Items   []*MergeItem
+	NewTags []string // Tags that are created in the target.
+}
+
+// merger holds the data to plan and run a merge.
+type merger struct {
+	*Starrs
+	source   *instance
+	target   *instance
+	lib      *library
+	input    *MergeInput
+	profiles map[string]map[int64]int64  // Item field => source profile id => target profile id.
+	missing  map[string]map[int64]string // Item field => source profile id => name, for profiles the target lacks.
+	tags     map[int64]string            // Source tag id => label.
+	newTags  map[string]int64            // Target tag label (lowercase) => id.
+	roots    []string                    // Target root folders.
+	plan     *MergePlan
+	items    []LibraryItem // Copies to add, in the same order as plan.Items.
+}
+
+// PreviewMerge returns what MergeInstances would do. Nothing is changed.
+func (s *Starrs) PreviewMerge(source, target *AppConfig, input *MergeInput) (*MergePlan, error) {
+	s.log.Tracef("Call:PreviewMerge(%s, %s => %s)", source.App, source.Name, target.Name)
+
+	merge, err := s.planMerge(source, target, input)
+	if err != nil {
+		return nil, err
+	}
+
+	merge.plan.Msg = merge.summary()
+
+	return merge.plan, nil
+}
+
+// MergeInstances copies items from one instance into another with the same app type.
+// Paths are moved to new root folders like the migrator does, quality and metadata profiles
+// are matched by name, and tags are matched by label and created when missing. The target
+// finds existing files when it scans the new items; history is not copied.
+func (s *Starrs) MergeInstances(source, target *AppConfig, input *MergeInput) (*MergePlan, error) {
+	s.log.Tracef("Call:MergeInstances(%s, %s => %s)", source.App, source.Name, target.Name)
+
+	merge, err := s.planMerge(source, target, input)
+	if err != nil {
+		return nil, err
+	}
+
+	question := s.log.Translate("Copy items from %s to %s?\n%s", source.Name, target.Name, merge.summary())
+	if !s.app.Ask(s.log.Translate("Merge Instances"), question) {
+		return &MergePlan{}, nil
+	}
+
+	if err := merge.createTags(); err != nil {
+		return nil, errors.New(s.log.Translate("Creating tags in %s: %v", target.Name, err.Error()))
+	}
+
+	merge.addItems()
+	merge.plan.Msg = merge.summary()
+
+	return merge.plan, nil
+}
+
+func (s *Starrs) planMerge(source, target *AppConfig, input *MergeInput) (*merger, error) {
+	if source.App != target.App {
+		return nil, errors.New(s.log.Translate("Cannot merge %s into %s. The apps must match.", source.App, target.App))
+	} else if getLibrary(source.App) == nil {
+		return nil, errors.New(s.log.Translate("%s has no library to merge.", source.App))
+	}
+
+	merge := &merger{
+		Starrs:   s,
+		lib:      getLibrary(source.App),
+		input:    input,
+		profiles: make(map[string]map[int64]int64),
+		missing:  make(map[string]map[int64]string),
+		tags:     make(map[int64]string),
+		newTags:  make(map[string]int64),
+		plan:     &MergePlan{Items: []*MergeItem{}, NewTags: []string{}},
+	}
+
+	var err error
+
+	if merge.source, err = s.newAPIinstance(source); err != nil {
+		return nil, err
+	} else if merge.target, err = s.newAPIinstance(target); err != nil {
+		return nil, err
+	}
+
+	if err = merge.load(); err != nil {
+		return nil, errors.New(s.log.Translate("Planning merge: %v", err.Error()))
+	}
+
+	return merge, nil
+}
+
+// load gets the items, profiles, tags and root folders, and plans each item.
+func (m *merger) load() error {
+	for field, kind := range m.lib.Profiles {
+		if err := m.mapProfiles(field, kind); err != nil {
+			return err
+		}
+	}
+
+	tags, err := m.Starrs.tags(m.source.config)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		m.tags[int64(tag.ID)] = tag.Label
+	}
+
+	if tags, err = m.Starrs.tags(m.target.config); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		m.newTags[strings.ToLower(tag.Label)] = int64(tag.ID)
+	}
+
+	if m.roots, err = m.target.rootPaths(); err != nil {
+		return err
+	}
+
+	existing, err := m.target.library()
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]int64, len(existing))
+	for _, item := range existing {
+		have[item.String(m.lib.Foreign)] = item.ID()
+	}
+
+	items, err := m.source.library()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if len(m.input.Selected) == 0 || m.input.Selected[item.ID()] {
+			m.planItem(item, have)
+		}
+	}
+
+	return nil
+}
+
+// mapProfiles matches the source profiles to the target profiles by name.
+func (m *merger) mapProfiles(field, kind string) error {
+	source, err := m.source.profiles(kind)
+	if err != nil {
+		return err
+	}
+
+	target, err := m.target.profiles(kind)
+	if err != nil {
+		return err
+	}
+
+	sourceNames, _ := names(source, "name")
+	_, targetIDs := names(target, "name")
+
+	m.profiles[field] = make(map[int64]int64)
+	m.missing[field] = make(map[int64]string)
+
+	for id, name := range sourceNames {
+		if targetID, ok := targetIDs[strings.ToLower(name)]; ok {
+			m.profiles[field][id] = targetID
+		} else {
+			m.missing[field][id] = name
+		}
+	}
+
+	return nil
+}
+
+// planItem decides what happens to one source item, and makes the copy to add.
+func (m *merger) planItem(item LibraryItem, have map[string]int64) {
+	plan := &MergeItem{
+		SourceID: item.ID(),
+		Title:    item.String(m.lib.Title),
+		Path:     item.Path(),
+		NewPath:  item.Path(),
+		Status:   MergeAdd,
+	}
+	m.plan.Items = append(m.plan.Items, plan)
+	m.items = append(m.items, nil)
+
+	if id, ok := have[item.String(m.lib.Foreign)]; ok {
+		plan.TargetID, plan.Status = id, MergeExists
+		return
+	}
+
+	root := ""
+	if newPath, newRoot, ok := mapPath(item.Path(), m.input.Folders); ok {
+		plan.NewPath, root = newPath, newRoot
+	} else if dirs, ok := itemInRoot(item.Path(), m.roots); ok {
+		root = longestRoot(dirs)
+	} else {
+		plan.Status = MergeSkip
+		plan.Msg = m.log.Translate("The path is not in a root folder in the target. Map its root folder.")
+
+		return
+	}
+
+	copied := item.copy()
+	for _, field := range []string{"id", "added", "statistics", "movieFile", "movieFileId", "lastInfoSync"} {
+		delete(copied, field)
+	}
+
+	copied["path"], copied["rootFolderPath"] = plan.NewPath, root
+	copied["addOptions"] = map[string]any{m.lib.Search: m.input.Search}
+
+	for field := range m.lib.Profiles {
+		sourceID := item.Int(field)
+		if name, ok := m.missing[field][sourceID]; ok {
+			plan.Status = MergeSkip
+			plan.Msg = m.log.Translate("The target has no profile named %s.", name)
+
+			return
+		}
+
+		copied[field] = m.profiles[field][sourceID]
+	}
+
+	labels := []string{}
+
+	for _, tag := range item.Tags() {
+		if label, ok := m.tags[tag]; ok {
+			labels = append(labels, label)
+
+			if _, ok := m.newTags[strings.ToLower(label)]; !ok && !slices.Contains(m.plan.NewTags, label) {
+				m.plan.NewTags = append(m.plan.NewTags, label)
+			}
+		}
+	}
+
+	copied["tags"] = labels // Replaced with target ids after the new tags are created.
+	m.items[len(m.items)-1] = copied
+}
+
+// createTags adds the missing tags to the target.
+func (m *merger) createTags() error {
+	for _, label := range m.plan.NewTags {
+		tag, err := m.addTag(m.target.config, label)
+		if err != nil {
+			return err
+		}
+
+		m.newTags[strings.ToLower(label)] = int64(tag.ID)
+	}
+
+	return nil
+}
+
+// addItems adds the planned items to the target, and emits each result.
+func (m *merger) addItems() {
+	for idx, plan := range m.plan.Items {
+		copied := m.items[idx]
+		if plan.Status != MergeAdd || copied == nil {
+			continue
+		}
+
+		labels, _ := copied["tags"].([]string)
+		tags := make([]int64, 0, len(labels))
+
+		for _, label := range labels {
+			tags = append(tags, m.newTags[strings.ToLower(label)])
+		}
+
+		copied["tags"] = tags
+
+		if id, err := m.target.addItem(copied); err != nil {
+			plan.Status, plan.Msg = MergeFailed, err.Error()
+		} else {
+			plan.Status, plan.TargetID = MergeAdded, id
+		}
+
+		m.app.Emit("MergeProgress", plan)
+	}
+}
+
+// summary counts the items in each status.
+func (m *merger) summary() string {
+	counts := make(map[string]int)
+	for _, item := range m.plan.Items {
+		counts[item.Status]++
+	}
+
+	return m.log.Translate("Add: %d, added: %d, failed: %d, already exist: %d, skipped: %d, new tags: %d.",
+		counts[MergeAdd], counts[MergeAdded], counts[MergeFailed], counts[MergeExists], counts[MergeSkip],
+		len(m.plan.NewTags))
+}
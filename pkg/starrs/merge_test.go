@@ -0,0 +1,216 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+// newMerge starts a source and a target radarr. Profiles have the same names with different ids.
+func newMerge(t *testing.T) (*starrtest.Server, *starrtest.Server, *AppConfig, *AppConfig) {
+	t.Helper()
+
+	source, target := starrtest.New(starr.Radarr), starrtest.New(starr.Radarr)
+	t.Cleanup(source.Close)
+	t.Cleanup(target.Close)
+
+	source.Seed("qualityprofile", starrtest.Item{"id": 1, "name": "HD"}, starrtest.Item{"id": 2, "name": "4K"})
+	source.Seed("tag", starrtest.Item{"id": 1, "label": "kids"}, starrtest.Item{"id": 2, "label": "new"})
+	source.Seed("movie",
+		starrtest.Item{"id": 1, "tmdbId": 10, "title": "Copied", "path": "/movies/Copied", "qualityProfileId": 1,
+			"tags": []int64{1, 2}, "movieFileId": 5},
+		starrtest.Item{"id": 2, "tmdbId": 20, "title": "Exists", "path": "/movies/Exists", "qualityProfileId": 1},
+		starrtest.Item{"id": 3, "tmdbId": 30, "title": "Unmapped", "path": "/other/Unmapped", "qualityProfileId": 1},
+		starrtest.Item{"id": 4, "tmdbId": 40, "title": "No Profile", "path": "/movies/NoProfile", "qualityProfileId": 2},
+	)
+
+	target.Seed("qualityprofile", starrtest.Item{"id": 7, "name": "hd"})
+	target.Seed("tag", starrtest.Item{"id": 3, "label": "Kids"})
+	target.Seed("rootfolder", starrtest.Item{"id": 1, "path": "/data/movies/"})
+	target.Seed("movie", starrtest.Item{"id": 9, "tmdbId": 20, "title": "Exists", "path": "/data/movies/Exists"})
+
+	sourceConfig, targetConfig := newConfig(source), newConfig(target)
+	targetConfig.Name = "target"
+
+	return source, target, sourceConfig, targetConfig
+}
+
+func mergeInput() *MergeInput {
+	return &MergeInput{Folders: map[string]string{"/movies": "/data/movies"}}
+}
+
+func TestPreviewMerge(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	_, target, source, dest := newMerge(t)
+
+	plan, err := test.PreviewMerge(source, dest, mergeInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]string{1: MergeAdd, 2: MergeExists, 3: MergeSkip, 4: MergeSkip}
+	if len(plan.Items) != len(want) {
+		t.Fatalf("wanted %d items, got: %d", len(want), len(plan.Items))
+	}
+
+	for _, item := range plan.Items {
+		if item.Status != want[item.SourceID] {
+			t.Errorf("item %d: wanted status %s, got: %s (%s)", item.SourceID, want[item.SourceID], item.Status, item.Msg)
+		}
+	}
+
+	if plan.Items[0].NewPath != "/data/movies/Copied" {
+		t.Errorf("the path should move to the mapped root folder: %s", plan.Items[0].NewPath)
+	}
+
+	if len(plan.NewTags) != 1 || plan.NewTags[0] != "new" {
+		t.Errorf("only the tag missing in the target should be new: %v", plan.NewTags)
+	}
+
+	if len(target.Items("movie")) != 1 || target.Called("POST /api/v3/tag") {
+		t.Error("a preview must not change the target")
+	}
+
+	sonarr := *dest
+	sonarr.App = starr.Sonarr.String()
+
+	if _, err := test.PreviewMerge(source, &sonarr, mergeInput()); err == nil {
+		t.Error("merging different apps should return an error")
+	}
+}
+
+func TestMergeInstances(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	_, target, source, dest := newMerge(t)
+
+	test.host.Answer = false
+
+	if plan, err := test.MergeInstances(source, dest, mergeInput()); err != nil || len(plan.Items) != 0 {
+		t.Fatalf("declining should not merge: %v, %v", plan, err)
+	}
+
+	test.host.Answer = true
+
+	plan, err := test.MergeInstances(source, dest, mergeInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Items[0].Status != MergeAdded || plan.Items[0].TargetID == 0 {
+		t.Fatalf("the first item should be added: %+v", plan.Items[0])
+	}
+
+	movies := target.Items("movie")
+	if len(movies) != 2 {
+		t.Fatalf("one movie should be added: %v", movies)
+	}
+
+	added := movies[1]
+	if added["path"] != "/data/movies/Copied" || added["rootFolderPath"] != "/data/movies" {
+		t.Errorf("wrong path in the added movie: %v, %v", added["path"], added["rootFolderPath"])
+	}
+
+	if added["qualityProfileId"] != float64(7) {
+		t.Errorf("the profile should be matched by name: %v", added["qualityProfileId"])
+	}
+
+	if _, ok := added["movieFileId"]; ok {
+		t.Error("file ids must not be copied")
+	}
+
+	tags := target.Items("tag")
+	if len(tags) != 2 || tags[1]["label"] != "new" {
+		t.Fatalf("the missing tag should be created: %v", tags)
+	}
+
+	want := []any{float64(3), float64(tags[1].ID())}
+	if got, _ := added["tags"].([]any); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("tags should use the target ids: wanted %v, got: %v", want, added["tags"])
+	}
+}
+
+func TestMapPath(t *testing.T) {
+	t.Parallel()
+
+	folders := map[string]string{"/data/tv": "/new/tv", "/data/tv4k/": "/new/4k/"}
+
+	tests := map[string]string{
+		"/data/tv/Show":   "/new/tv/Show",
+		"/data/tv4k/Show": "/new/4k/Show",
+	}
+
+	for path, want := range tests {
+		if got, _, ok := mapPath(path, folders); !ok || got != want {
+			t.Errorf("%s: wanted %s, got: %s", path, want, got)
+		}
+	}
+
+	if _, _, ok := mapPath("/other/Show", folders); ok {
+		t.Error("a path outside the mapped folders should not map")
+	}
+}
+
+func TestMergeNestedRoots(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	_, target, source, dest := newMerge(t)
+
+	// The item is in both root folders. The deepest one is its root folder.
+	target.Seed("rootfolder", starrtest.Item{"id": 2, "path": "/"}, starrtest.Item{"id": 3, "path": "/movies/"})
+
+	plan, err := test.PreviewMerge(source, dest, &MergeInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Items[0].Status != MergeAdd || plan.Items[0].NewPath != "/movies/Copied" {
+		t.Fatalf("the item should be added where it is: %+v", plan.Items[0])
+	}
+
+	if _, err = test.MergeInstances(source, dest, &MergeInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if movies := target.Items("movie"); len(movies) < 2 || movies[1]["rootFolderPath"] != "/movies/" {
+		t.Errorf("the deepest root folder should be used: %v", movies)
+	}
+}
+
+// TestMergeSeries adds a series with the starr library's typed calls.
+func TestMergeSeries(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	source, target := starrtest.New(starr.Sonarr), starrtest.New(starr.Sonarr)
+	t.Cleanup(source.Close)
+	t.Cleanup(target.Close)
+
+	source.Seed("qualityprofile", starrtest.Item{"id": 1, "name": "HD"})
+	source.Seed("tag", starrtest.Item{"id": 1, "label": "kids"})
+	source.Seed("series", starrtest.Item{"id": 1, "tvdbId": 10, "title": "Show", "path": "/tv/Show",
+		"qualityProfileId": 1, "tags": []int64{1}, "seasonFolder": true})
+	target.Seed("qualityprofile", starrtest.Item{"id": 4, "name": "HD"})
+	target.Seed("rootfolder", starrtest.Item{"id": 1, "path": "/data/tv/"})
+
+	plan, err := test.MergeInstances(newConfig(source), newConfig(target),
+		&MergeInput{Folders: map[string]string{"/tv": "/data/tv"}})
+	if err != nil || plan.Items[0].Status != MergeAdded {
+		t.Fatalf("the series should be added: %v, %+v", err, plan)
+	}
+
+	series, tags := target.Items("series"), target.Items("tag")
+	if len(series) != 1 || series[0]["path"] != "/data/tv/Show" || series[0]["qualityProfileId"] != float64(4) ||
+		series[0]["seasonFolder"] != true {
+		t.Errorf("wrong series added: %v", series)
+	}
+
+	if len(tags) != 1 || tags[0]["label"] != "kids" || !target.Called("POST /api/v3/series") {
+		t.Errorf("the tag should be created, and the series added with the series api: %v", tags)
+	}
+}
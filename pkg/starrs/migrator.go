@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/Notifiarr/toolbarr/pkg/mnd"
-	wr "github.com/wailsapp/wails/v2/pkg/runtime"
 	"modernc.org/sqlite"
 	sqlite3 "modernc.org/sqlite/lib"
 )
@@ -91,7 +90,7 @@ func (s *Starrs) MigratorInfo(config *AppConfig) (*MigratorInfo, error) {
 
 	info, err := s.migratorInfo(sql, config)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
 	}
 
 	return info, nil
@@ -108,6 +107,11 @@ func (s *Starrs) DeleteRootFolder(config *AppConfig, folder string) (*RootFolder
 		return &RootFolders{}, nil
 	}
 
+	guard, err := s.guardMigrator(config)
+	if err != nil {
+		return nil, err
+	}
+
 	sql, err := s.newSQL(config)
 	if err != nil {
 		return nil, err
@@ -116,16 +120,21 @@ func (s *Starrs) DeleteRootFolder(config *AppConfig, folder string) (*RootFolder
 
 	_, err = sql.Delete("RootFolders", fmt.Sprintf("Path='%s'", folder))
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
 	}
 
-	return s.returnMessage(sql, config, s.log.Translate("Success! Deleted root folder: %s", folder))
+	return s.returnMessage(sql, config, guard, s.log.Translate("Success! Deleted root folder: %s", folder))
 }
 
 // UpdateRootFolder changes the path for a root folder. It updates all the items with the folder.
 func (s *Starrs) UpdateRootFolder(config *AppConfig, oldPath, newPath string) (*RootFolders, error) {
 	s.log.Tracef("Call:UpdateDBRootFolder(%s,%s,%s)", config.Name, oldPath, newPath)
 
+	guard, err := s.guardMigrator(config)
+	if err != nil {
+		return nil, err
+	}
+
 	sql, err := s.newSQL(config)
 	if err != nil {
 		return nil, err
@@ -134,10 +143,10 @@ func (s *Starrs) UpdateRootFolder(config *AppConfig, oldPath, newPath string) (*
 
 	msg, err := s.updateRootFolder(AppTables(config.App), sql, oldPath, newPath)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
 	}
 
-	return s.returnMessage(sql, config, msg)
+	return s.returnMessage(sql, config, guard, msg)
 }
 
 func (s *Starrs) UpdateRecycleBin(config *AppConfig, newPath string) (*RootFolders, error) {
@@ -150,6 +159,11 @@ func (s *Starrs) UpdateRecycleBin(config *AppConfig, newPath string) (*RootFolde
 		}
 	}
 
+	guard, err := s.guardMigrator(config)
+	if err != nil {
+		return nil, err
+	}
+
 	sql, err := s.newSQL(config)
 	if err != nil {
 		return nil, err
@@ -158,7 +172,7 @@ func (s *Starrs) UpdateRecycleBin(config *AppConfig, newPath string) (*RootFolde
 
 	count, err := sql.UpdateRecyclebin(s.ctx, newPath)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
 	}
 
 	msg := s.log.Translate("Changed recycle bin path! Rows Updated: %d", count)
@@ -166,7 +180,7 @@ func (s *Starrs) UpdateRecycleBin(config *AppConfig, newPath string) (*RootFolde
 		msg = s.log.Translate("Unset recycle bin path! Rows Updated: %d", count)
 	}
 
-	return s.returnMessage(sql, config, msg)
+	return s.returnMessage(sql, config, guard, msg)
 }
 
 func (s *Starrs) UpdateInvalidItems(
@@ -178,7 +192,12 @@ func (s *Starrs) UpdateInvalidItems(
 	s.log.Tracef("Call:UpdateDBInvalidItems(%s,%s,%d)", config.Name, table, len(ids))
 
 	if newPath == "" {
-		return nil, fmt.Errorf(s.log.Translate("No path provided."))
+		return nil, errors.New(s.log.Translate("No path provided."))
+	}
+
+	guard, err := s.guardMigrator(config)
+	if err != nil {
+		return nil, err
 	}
 
 	sql, err := s.newSQL(config)
@@ -195,7 +214,7 @@ func (s *Starrs) UpdateInvalidItems(
 		}
 	}
 
-	wr.EventsEmit(s.ctx, "DBitemTotals", counts)
+	s.app.Emit("DBitemTotals", counts)
 
 	column := AppTables(config.App)[table]
 
@@ -206,16 +225,35 @@ func (s *Starrs) UpdateInvalidItems(
 
 	msg, err := fn(sql, &column, newPath, ids)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	}
+
+	return s.returnMessage(sql, config, guard, msg)
+}
+
+// guardMigrator runs the in-use and schema checks before the migrator writes to a database.
+// The in-use check runs first, because it looks for the app's WAL and SHM files before the database is opened.
+func (s *Starrs) guardMigrator(config *AppConfig) (dbGuard, error) {
+	guard, err := s.guardDB(config)
+	if err != nil {
+		return guardNone, err
 	}
 
-	return s.returnMessage(sql, config, msg)
+	if err := s.guardSchema(config); err != nil {
+		return guardNone, err
+	}
+
+	return guard, nil
 }
 
-func (s *Starrs) returnMessage(sql *sqlConn, config *AppConfig, msg string) (*RootFolders, error) {
+// returnMessage reads the new root folder info, and closes the database before afterWrite restarts the app.
+func (s *Starrs) returnMessage(sql *sqlConn, config *AppConfig, guard dbGuard, msg string) (*RootFolders, error) {
 	info, err := s.migratorInfo(sql, config)
+	sql.Close()
+
+	msg = s.afterWrite(config, guard, msg)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
 	}
 
 	return &RootFolders{Msg: msg, Info: info}, nil
@@ -242,7 +280,7 @@ func (s *Starrs) updateInvalidBasePath(
 		}
 
 		counter++
-		wr.EventsEmit(s.ctx, "DBfileCount", map[string]int{column.Table: counter})
+		s.app.Emit("DBfileCount", map[string]int{column.Table: counter})
 
 		updatePath := newPath + filepath.Base(FromSlash(entry.Path))
 		idStr := "Id=" + strconv.FormatUint(entry.ID, mnd.Base10)
@@ -275,7 +313,7 @@ func (s *Starrs) updateInvalidRootFolders(
 		}
 
 		counter++
-		wr.EventsEmit(s.ctx, "DBfileCount", map[string]int{column.Table: counter})
+		s.app.Emit("DBfileCount", map[string]int{column.Table: counter})
 
 		res, err := sql.Update(column.Table, column.Column, newPath, fmt.Sprint("Id=", rowID))
 		if err != nil {
@@ -295,7 +333,7 @@ func (s *Starrs) updateRootFolder(appTable AppTable, sql *sqlConn, oldPath, newP
 		return "", err
 	}
 
-	wr.EventsEmit(s.ctx, "DBitemTotals", counts)
+	s.app.Emit("DBitemTotals", counts)
 
 	_, err = sql.Update("RootFolders", "Path", newPath, fmt.Sprintf("Path='%s'", oldPath))
 	if err != nil {
@@ -367,7 +405,7 @@ func (s *Starrs) updateFilesRootFolder(
 
 	for _, entry := range files {
 		counter++
-		wr.EventsEmit(s.ctx, "DBfileCount", map[string]int64{table.Table: counter})
+		s.app.Emit("DBfileCount", map[string]int64{table.Table: counter})
 
 		if !strings.HasPrefix(entry.Path, oldPath) {
 			s.log.Debugf("Skipping path (wrong prefix): %s", entry.Path)
@@ -0,0 +1,358 @@
+package starrs
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+// inserts add a row with an id and a path to each table in AppTables.
+//
+//nolint:gochecknoglobals,lll
+var inserts = map[string]string{
+	"Movies":      `INSERT INTO Movies (Id, Path, Monitored, QualityProfileId, MovieFileId, MinimumAvailability, MovieMetadataId) VALUES (%[1]d, '%[2]s', 1, 1, 0, 0, %[1]d)`,
+	"Series":      `INSERT INTO Series (Id, TvdbId, Title, Path, Monitored) VALUES (%[1]d, %[1]d, 'show', '%[2]s', 1)`,
+	"Artists":     `INSERT INTO Artists (Id, ArtistMetadataId, Path, Monitored) VALUES (%[1]d, %[1]d, '%[2]s', 1)`,
+	"Authors":     `INSERT INTO Authors (Id, AuthorMetadataId, Path, Monitored) VALUES (%[1]d, %[1]d, '%[2]s', 1)`,
+	"TrackFiles":  `INSERT INTO TrackFiles (Id, AlbumId, Quality, Size, OriginalFilePath, Path) VALUES (%[1]d, 1, '{}', 1, 'track', '%[2]s')`,
+	"BookFiles":   `INSERT INTO BookFiles (Id, EditionId, CalibreId, Quality, Size, OriginalFilePath, Path) VALUES (%[1]d, 1, 0, '{}', 1, 'book', '%[2]s')`,
+	"ImportLists": `INSERT INTO ImportLists (Id, Name, Implementation, RootFolderPath) VALUES (%[1]d, 'list%[1]d', 'Fixture', '%[2]s')`,
+	"Collections": `INSERT INTO Collections (Id, TmdbId, QualityProfileId, RootFolderPath, Title, Monitored) VALUES (%[1]d, %[1]d, 1, '%[2]s', 'collection%[1]d', 1)`,
+}
+
+func insert(table string, id int, path string) string {
+	return fmt.Sprintf(inserts[table], id, Escape(path))
+}
+
+func rootFolder(path string) string {
+	return fmt.Sprintf("INSERT INTO RootFolders (Path) VALUES ('%s')", Escape(path))
+}
+
+// newDB creates a fixture database and returns a tester and an instance config to use it.
+func newDB(t *testing.T, app starr.App, queries ...string) (*tester, *AppConfig) {
+	t.Helper()
+
+	config := &AppConfig{App: app.String(), Name: "test" + app.String(), DBPath: filepath.Join(t.TempDir(), "app.db")}
+	if err := starrtest.NewDB(config.DBPath, app, queries...); err != nil {
+		t.Fatalf("creating %s database: %v", app, err)
+	}
+
+	return newTester(t), config
+}
+
+// rows returns one column from the fixture database.
+func (s *tester) rows(t *testing.T, config *AppConfig, query string) []string {
+	t.Helper()
+
+	sql, err := s.newSQL(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sql.Close()
+
+	rows, err := sql.RowsStringSlice(s.ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(rows)
+
+	return rows
+}
+
+func TestItemInRoot(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		item    string
+		folders []string
+		dirs    []string
+		found   bool
+	}{
+		{"/movies/Film (2000)", []string{"/movies/", "/tv/"}, []string{"/movies/"}, true},
+		{"/movies/Film (2000)", []string{"/movies"}, []string{"/movies"}, true},
+		{"/movies2/Film (2000)", []string{"/movies"}, []string{}, false},
+		{"/data/movies/Film", []string{"/data/", "/data/movies"}, []string{"/data/", "/data/movies"}, true},
+		{`C:\Movies\Film (2000)`, []string{`C:\Movies`, `D:\TV\`}, []string{`C:\Movies`}, true},
+		{`C:\Movies2\Film (2000)`, []string{`C:\Movies`}, []string{}, false},
+		{`\\nas\share\Film`, []string{`\\nas\share`}, []string{`\\nas\share`}, true},
+		{`\\nas\share\Film`, []string{`\\nas\shares\`}, []string{}, false},
+		{"/movies/Film", []string{}, []string{}, false},
+	}
+
+	for _, test := range tests {
+		dirs, found := itemInRoot(test.item, test.folders)
+		if found != test.found || !reflect.DeepEqual(dirs, test.dirs) {
+			t.Errorf("itemInRoot(%q, %q) = %q, %v; want %q, %v", test.item, test.folders, dirs, found, test.dirs, test.found)
+		}
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]string{
+		"/movies":         "/movies/",
+		"/movies/":        "/movies/",
+		`C:\Movies`:       `C:\Movies\`,
+		`C:\Movies\`:      `C:\Movies\`,
+		`\\nas\share`:     `\\nas\share\`,
+		"C:/Movies":       "C:/Movies/",
+		"":                "/",
+		`D:\a\b/c`:        `D:\a\b/c\`,
+		"relative/folder": "relative/folder/",
+	} {
+		if got := trailingSlash(input); got != want {
+			t.Errorf("trailingSlash(%q) = %q; want %q", input, got, want)
+		}
+	}
+}
+
+func TestDerive(t *testing.T) {
+	t.Parallel()
+
+	movies := TableColumn{Table: "Movies", Column: "Path"}
+	lists := TableColumn{Table: "ImportLists", Column: "RootFolderPath"}
+	outside := &Entry{ID: 3, Path: "/downloads/Film"}
+	windows := &Entry{ID: 4, Path: `E:\Film`}
+	info := (&MigratorInfo{
+		RootFolders: []string{"/movies/", `D:\Movies`},
+		Folders:     make(TableCountMap),
+		Invalid:     make(map[string][]*Entry),
+	}).derive(TableFileMap{
+		movies: {{ID: 1, Path: "/movies/A"}, {ID: 2, Path: `D:\Movies\B`}, outside, windows},
+		lists:  {},
+	})
+
+	want := TableCountMap{"Movies": {"/movies/": 1, `D:\Movies`: 1}, "ImportLists": {}}
+	if !reflect.DeepEqual(info.Folders, want) {
+		t.Errorf("wrong folder counts: %v; want %v", info.Folders, want)
+	}
+
+	if invalid := info.Invalid["Movies"]; len(invalid) != 2 || invalid[0] != outside || invalid[1] != windows {
+		t.Errorf("wrong invalid items: %v", invalid)
+	}
+
+	if _, ok := info.Invalid["ImportLists"]; ok {
+		t.Errorf("import lists should have no invalid items")
+	}
+}
+
+// TestMigratorInfo reads every table in AppTables for every app.
+func TestMigratorInfo(t *testing.T) {
+	t.Parallel()
+
+	for _, app := range starrtest.Apps() {
+		queries := []string{
+			rootFolder("/data/a/"),
+			rootFolder(`C:\Data\B\`),
+			"INSERT INTO Config (Key, Value) VALUES ('recyclebin', '/trash/')",
+		}
+
+		for name := range AppTables(app.String()) {
+			queries = append(queries,
+				insert(name, 1, "/data/a/item"), insert(name, 2, `C:\Data\B\item`), insert(name, 3, "/elsewhere/item"))
+		}
+
+		test, config := newDB(t, app, queries...)
+
+		info, err := test.MigratorInfo(config)
+		if err != nil {
+			t.Fatalf("%s: %v", app, err)
+		}
+
+		if info.Recycle != "/trash/" || len(info.RootFolders) != 2 || !reflect.DeepEqual(info.Table, AppTables(app.String())) {
+			t.Errorf("%s: wrong info: %+v", app, info)
+		}
+
+		for _, column := range AppTables(app.String()) {
+			if counts := info.Folders[column.Table]; counts["/data/a/"] != 1 || counts[`C:\Data\B\`] != 1 {
+				t.Errorf("%s: %s: wrong folder counts: %v", app, column.Table, counts)
+			}
+
+			if invalid := info.Invalid[column.Table]; len(invalid) != 1 || invalid[0].Path != "/elsewhere/item" {
+				t.Errorf("%s: %s: wrong invalid items: %v", app, column.Table, invalid)
+			}
+		}
+	}
+}
+
+func TestUpdateRootFolder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		old, new    string
+		items, want []string
+	}{{
+		old: "/data/a/", new: "/media/a/",
+		items: []string{"/data/a/Film", "/data/ab/Film", "/other/data/a/Film"},
+		want:  []string{"/data/ab/Film", "/media/a/Film", "/other/data/a/Film"},
+	}, {
+		old: `C:\Movies\`, new: `D:\Films\`,
+		items: []string{`C:\Movies\Film`, `C:\Movies2\Film`, `c:\movies\Film`},
+		want:  []string{`C:\Movies2\Film`, `D:\Films\Film`, `c:\movies\Film`},
+	}, {
+		old: `\\nas\movies\`, new: "/mnt/movies/",
+		items: []string{`\\nas\movies\Film`, `\\nas\movies-4k\Film`},
+		want:  []string{`/mnt/movies/Film`, `\\nas\movies-4k\Film`},
+	}}
+
+	for _, test := range tests {
+		queries := []string{rootFolder(test.old), rootFolder("/unrelated/")}
+		for idx, item := range test.items {
+			queries = append(queries, insert("Movies", idx+1, item), insert("ImportLists", idx+1, item),
+				insert("Collections", idx+1, item))
+		}
+
+		starrs, config := newDB(t, starr.Radarr, queries...)
+
+		reply, err := starrs.UpdateRootFolder(config, test.old, test.new)
+		if err != nil {
+			t.Fatalf("moving %s: %v", test.old, err)
+		}
+
+		folders := strings.Join(reply.Info.RootFolders, "|")
+		if len(reply.Info.RootFolders) != 2 || strings.Contains(folders, test.old) || !strings.Contains(folders, test.new) {
+			t.Errorf("root folder %s was not renamed: %v", test.old, reply.Info.RootFolders)
+		}
+
+		for _, table := range []string{"Movies", "ImportLists", "Collections"} {
+			column := AppTables(config.App)[table].Column
+			if got := starrs.rows(t, config, "SELECT "+column+" FROM "+table); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: moving %s: got %q; want %q", table, test.old, got, test.want)
+			}
+		}
+	}
+}
+
+// TestMergeRootFolders renames a root folder to one that exists, which violates a UNIQUE index.
+// The user is asked to merge them.
+func TestMergeRootFolders(t *testing.T) {
+	t.Parallel()
+
+	for _, merge := range []bool{true, false} {
+		test, config := newDB(t, starr.Sonarr, rootFolder(`D:\TV\`), rootFolder(`E:\TV\`),
+			insert("Series", 1, `D:\TV\Show`), insert("Series", 2, `E:\TV\Other`))
+		test.host.Answer = merge
+
+		_, err := test.UpdateRootFolder(config, `D:\TV\`, `E:\TV\`)
+		folders := test.rows(t, config, "SELECT Path FROM RootFolders")
+		series := test.rows(t, config, "SELECT Path FROM Series")
+
+		switch {
+		case !merge && (err == nil || !strings.Contains(err.Error(), "UNIQUE")):
+			t.Errorf("declining the merge should return the constraint error, got: %v", err)
+		case !merge && (len(folders) != 2 || series[0] != `D:\TV\Show`):
+			t.Errorf("declining the merge should change nothing: %q, %q", folders, series)
+		case merge && err != nil:
+			t.Errorf("merging: %v", err)
+		case merge && !reflect.DeepEqual(folders, []string{`E:\TV\`}):
+			t.Errorf("merging should leave one root folder, got: %q", folders)
+		case merge && !reflect.DeepEqual(series, []string{`E:\TV\Other`, `E:\TV\Show`}):
+			t.Errorf("merging should move the series, got: %q", series)
+		}
+	}
+}
+
+func TestDeleteRootFolder(t *testing.T) {
+	t.Parallel()
+
+	for _, answer := range []bool{false, true} {
+		test, config := newDB(t, starr.Lidarr, rootFolder("/music/"), rootFolder(`C:\Music\`))
+		test.host.Answer = answer
+
+		if _, err := test.DeleteRootFolder(config, `C:\Music\`); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"/music/", `C:\Music\`}
+		if answer {
+			want = want[:1]
+		}
+
+		if got := test.rows(t, config, "SELECT Path FROM RootFolders"); !reflect.DeepEqual(got, want) {
+			t.Errorf("answer %v: got %q; want %q", answer, got, want)
+		}
+	}
+}
+
+func TestUpdateInvalidItems(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Readarr, rootFolder("/books/"), rootFolder(`D:\Books\`),
+		insert("Authors", 1, "/old/Author One"), insert("Authors", 2, `E:\Old\Author Two`),
+		insert("Authors", 3, "/old/Author Three"), insert("Authors", 4, `F:\Old\Author Four\`),
+		insert("ImportLists", 1, "/old/"), insert("ImportLists", 2, "/old/"))
+
+	emitted := map[string]int{}
+	test.host.OnEmit = func(event string, _ any) { emitted[event]++ }
+
+	if _, err := test.UpdateInvalidItems(config, "Authors", "", map[int64]bool{1: true}); err == nil {
+		t.Error("an empty path should return an error")
+	}
+
+	reply, err := test.UpdateInvalidItems(config, "Authors", "/books/", map[int64]bool{1: true, 2: true, 3: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = test.UpdateInvalidItems(config, "Authors", `D:\Books\`, map[int64]bool{4: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/books/Author One", "/books/Author Two", "/old/Author Three", `D:\Books\Author Four`}
+	sort.Strings(want)
+
+	if got := test.rows(t, config, "SELECT Path FROM Authors"); !reflect.DeepEqual(got, want) {
+		t.Errorf("authors: got %q; want %q", got, want)
+	}
+
+	if len(reply.Info.Invalid["Authors"]) != 2 || emitted["DBitemTotals"] != 2 || emitted["DBfileCount"] != 3 {
+		t.Errorf("wrong reply or events: %v, %v", reply.Info.Invalid, emitted)
+	}
+
+	if _, err = test.UpdateInvalidItems(config, "ImportLists", `D:\Books\`, map[int64]bool{2: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := test.rows(t, config, "SELECT RootFolderPath FROM ImportLists"); !reflect.DeepEqual(got, []string{"/old/", `D:\Books\`}) {
+		t.Errorf("import lists: got %q", got)
+	}
+}
+
+func TestUpdateRecycleBin(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr)
+	steps := []struct {
+		path   string
+		answer bool
+		want   string
+	}{
+		{path: "/trash/", want: "/trash/"},
+		{path: `C:\Recycle Bin\`, want: `C:\Recycle Bin\`}, // updates the existing row.
+		{path: "", answer: false, want: `C:\Recycle Bin\`},
+		{path: "", answer: true, want: ""},
+		{path: "/it's/", want: "/it's/"},
+	}
+
+	for _, step := range steps {
+		test.host.Answer = step.answer
+
+		reply, err := test.UpdateRecycleBin(config, step.path)
+		if err != nil {
+			t.Fatalf("setting recycle bin %q: %v", step.path, err)
+		}
+
+		if got := test.rows(t, config, "SELECT Value FROM Config WHERE Key='recyclebin'"); len(got) > 1 ||
+			strings.Join(got, "") != step.want || reply.Info != nil && reply.Info.Recycle != step.want {
+			t.Errorf("setting recycle bin %q: got %q; want %q", step.path, got, step.want)
+		}
+	}
+}
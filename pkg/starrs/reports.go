@@ -0,0 +1,157 @@
+package starrs
+
+import (
+	"errors"
+	"strings"
+)
+
+/* Named, parameterized reports that run in the read-only SQL explorer. */
+
+// Report is a named query. Built-in reports ship with toolbarr; others are saved by the user.
+type Report struct {
+	Name    string
+	Desc    string
+	App     string // Only show for this app. Empty for every app.
+	SQL     string
+	Params  []ReportParam
+	Builtin bool // Built-in reports cannot be changed or removed.
+}
+
+// ReportParam is a named parameter in a report, used as :name in the SQL.
+type ReportParam struct {
+	Name    string
+	Desc    string
+	Default string
+}
+
+// reportTables are the tables the built-in reports use for an app.
+type reportTables struct {
+	Items      string // The main item table: Series, Movies, etc.
+	Files      string // The file table for items.
+	FileItem   string // Joins files (f) to items (i).
+	Profiles   string // The quality profile table.
+	NoMetadata string // Matches items (i) without metadata.
+}
+
+// getReportTables returns the tables the built-in reports use for an app.
+func getReportTables(app string) *reportTables {
+	switch app {
+	case "Lidarr":
+		return &reportTables{
+			Items: "Artists", Files: "TrackFiles", Profiles: "QualityProfiles",
+			FileItem:   "f.AlbumId IN (SELECT a.Id FROM Albums a WHERE a.ArtistMetadataId = i.ArtistMetadataId)",
+			NoMetadata: "i.ArtistMetadataId NOT IN (SELECT Id FROM ArtistMetadata)",
+		}
+	case "Radarr":
+		return &reportTables{
+			Items: "Movies", Files: "MovieFiles", Profiles: "Profiles",
+			FileItem:   "f.MovieId = i.Id",
+			NoMetadata: "i.MovieMetadataId NOT IN (SELECT Id FROM MovieMetadata)",
+		}
+	case "Readarr":
+		return &reportTables{
+			Items: "Authors", Files: "BookFiles", Profiles: "QualityProfiles",
+			FileItem:   pathInSQL("f.Path", "i.Path"),
+			NoMetadata: "i.AuthorMetadataId NOT IN (SELECT Id FROM AuthorMetadata)",
+		}
+	case "Sonarr", "Whisparr":
+		return &reportTables{
+			Items: "Series", Files: "EpisodeFiles", Profiles: "QualityProfiles",
+			FileItem:   "f.SeriesId = i.Id",
+			NoMetadata: "i.LastInfoSync IS NULL",
+		}
+	default:
+		return nil
+	}
+}
+
+// pathInSQL returns sql that is true when path is inside folder. The folder is compared with a trailing
+// separator, so /movies2 is not inside /movies. Folders with a backslash are Windows paths.
+func pathInSQL(path, folder string) string {
+	root := "rtrim(" + folder + `, '/\') || CASE WHEN instr(` + folder + `, '\') > 0 THEN '\' ELSE '/' END`
+	return "substr(" + path + ", 1, length(" + root + ")) = " + root
+}
+
+// ranksCTE ranks the qualities in every profile. Profile items are ordered lowest to highest.
+// Groups have an id and a list of items. Single qualities have a quality id and an empty list.
+const ranksCTE = `WITH ranks AS (
+  SELECT p.Id AS Profile, CAST(top.key AS INTEGER) AS Rank,
+    coalesce(json_extract(top.value, '$.quality'), json_extract(top.value, '$.id')) AS TopID,
+    coalesce(json_extract(sub.value, '$.quality'), json_extract(top.value, '$.quality')) AS Quality
+  FROM {Profiles} p, json_each(p.Items) top LEFT JOIN json_each(top.value, '$.items') sub
+)
+`
+
+// BuiltinReports returns the built-in reports for an app.
+func BuiltinReports(app string) []*Report {
+	tables := getReportTables(app)
+	if tables == nil {
+		return []*Report{}
+	}
+
+	reports := []*Report{{
+		Name: "Duplicate Paths",
+		Desc: "Items that share a folder. Paths are compared without case.",
+		SQL: "SELECT i.Path, count(1) AS Items FROM {Items} i GROUP BY i.Path COLLATE NOCASE " +
+			"HAVING count(1) > 1 ORDER BY Items DESC",
+	}, {
+		Name: "Items Outside Root Folders",
+		Desc: "Items with a path that is not inside any root folder.",
+		SQL: "SELECT i.Id, i.Path FROM {Items} i WHERE NOT EXISTS (SELECT 1 FROM RootFolders r " +
+			"WHERE " + pathInSQL("i.Path", "r.Path") + ") ORDER BY i.Path",
+	}, {
+		Name: "Files Below Cutoff",
+		Desc: "Files with a quality lower than the cutoff in their item's quality profile.",
+		SQL: ranksCTE + "SELECT i.Id, i.Path, f.Id AS FileId, json_extract(f.Quality, '$.quality') AS Quality, " +
+			"p.Name AS Profile FROM {Files} f JOIN {Items} i ON {FileItem} JOIN {Profiles} p ON p.Id = i.QualityProfileId " +
+			"WHERE (SELECT min(Rank) FROM ranks WHERE Profile = p.Id AND Quality = json_extract(f.Quality, '$.quality')) " +
+			"< (SELECT min(Rank) FROM ranks WHERE Profile = p.Id AND TopID = p.Cutoff) ORDER BY i.Path",
+	}, {
+		Name: "Largest Items",
+		Desc: "Items using the most disk space.",
+		SQL: "SELECT i.Id, i.Path, count(f.Id) AS Files, sum(f.Size) AS Size FROM {Items} i " +
+			"JOIN {Files} f ON {FileItem} GROUP BY i.Id ORDER BY Size DESC LIMIT :count",
+		Params: []ReportParam{{Name: "count", Desc: "How many items to show.", Default: "25"}},
+	}, {
+		Name: "Items Without Metadata",
+		Desc: "Items that are missing metadata, or have never been refreshed.",
+		SQL:  "SELECT i.Id, i.Path FROM {Items} i WHERE {NoMetadata} ORDER BY i.Path",
+	}, {
+		Name: "Unmonitored Items With Files",
+		Desc: "Items that are not monitored, but have files on disk.",
+		SQL: "SELECT i.Id, i.Path, count(f.Id) AS Files FROM {Items} i JOIN {Files} f ON {FileItem} " +
+			"WHERE i.Monitored = 0 GROUP BY i.Id ORDER BY i.Path",
+	}}
+
+	replacer := strings.NewReplacer("{Items}", tables.Items, "{Files}", tables.Files,
+		"{FileItem}", tables.FileItem, "{Profiles}", tables.Profiles, "{NoMetadata}", tables.NoMetadata)
+
+	for _, report := range reports {
+		report.App = app
+		report.SQL = replacer.Replace(report.SQL)
+		report.Builtin = true
+	}
+
+	return reports
+}
+
+// RunReport runs a report against a read-only connection to a database.
+// Parameters that are not provided use their defaults.
+func (s *Starrs) RunReport(config *AppConfig, report *Report, params map[string]string) (*QueryResult, error) {
+	s.log.Tracef("Call:RunReport(%s, %s, %s)", config.App, config.Name, report.Name)
+
+	if report.App != "" && report.App != config.App {
+		return nil, errors.New(s.log.Translate("Report %s is for %s, not %s.", report.Name, report.App, config.App))
+	}
+
+	query := &Query{SQL: report.SQL, Params: make(map[string]string)}
+
+	for _, param := range report.Params {
+		query.Params[param.Name] = param.Default
+		if value, ok := params[param.Name]; ok && value != "" {
+			query.Params[param.Name] = value
+		}
+	}
+
+	return s.RunQuery(config, query)
+}
@@ -0,0 +1,146 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+// profile has one quality below a group cutoff with two qualities.
+const profile = `INSERT INTO QualityProfiles (Id, Name, Cutoff, Items) VALUES (1, 'HD', 1000,
+	'[{"quality": 1, "items": [], "allowed": true},
+	  {"id": 1000, "name": "HD", "items": [{"quality": 3, "items": []}, {"quality": 4, "items": []}], "allowed": true}]')`
+
+// report returns a built-in report by name.
+func report(t *testing.T, app starr.App, name string) *Report {
+	t.Helper()
+
+	for _, report := range BuiltinReports(app.String()) {
+		if report.Name == name {
+			return report
+		}
+	}
+
+	t.Fatalf("%s: no report named %s", app, name)
+
+	return nil
+}
+
+func TestBuiltinReports(t *testing.T) {
+	t.Parallel()
+
+	if reports := BuiltinReports(starr.Prowlarr.String()); len(reports) != 0 {
+		t.Errorf("prowlarr has no database reports: %v", reports)
+	}
+
+	// Every report must work with every app's schema.
+	for _, app := range starrtest.Apps() {
+		test, config := newDB(t, app)
+
+		for _, report := range BuiltinReports(app.String()) {
+			if _, err := test.RunReport(config, report, nil); err != nil {
+				t.Errorf("%s: %s: %v", app, report.Name, err)
+			}
+		}
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Sonarr, profile, rootFolder("/tv/"),
+		`INSERT INTO Series (Id, TvdbId, Title, Path, Monitored, QualityProfileId, LastInfoSync)
+			VALUES (1, 1, 'A', '/tv/A', 1, 1, '2024-01-01'), (2, 2, 'B', '/other/B', 0, 1, NULL)`,
+		`INSERT INTO EpisodeFiles (SeriesId, SeasonNumber, Size, Quality)
+			VALUES (1, 1, 100, '{"quality": 1}'), (1, 1, 200, '{"quality": 3}'), (2, 1, 50, '{"quality": 4}')`)
+
+	tests := map[string]struct {
+		params map[string]string
+		want   []any // first column of each row.
+	}{
+		"Items Outside Root Folders":   {want: []any{int64(2)}},
+		"Files Below Cutoff":           {want: []any{int64(1)}},
+		"Largest Items":                {want: []any{int64(1)}, params: map[string]string{"count": "1"}},
+		"Items Without Metadata":       {want: []any{int64(2)}},
+		"Unmonitored Items With Files": {want: []any{int64(2)}},
+		"Duplicate Paths":              {want: []any{}},
+	}
+
+	for name, check := range tests {
+		result, err := test.RunReport(config, report(t, starr.Sonarr, name), check.params)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(result.Rows) != len(check.want) {
+			t.Errorf("%s: wanted %d rows, got: %v", name, len(check.want), result.Rows)
+			continue
+		}
+
+		for idx, row := range result.Rows {
+			if row[0] != check.want[idx] {
+				t.Errorf("%s: row %d: wanted %v, got: %v", name, idx, check.want[idx], row)
+			}
+		}
+	}
+
+	if _, err := test.RunReport(config, report(t, starr.Radarr, "Largest Items"), nil); err == nil {
+		t.Error("running a radarr report on sonarr should return an error")
+	}
+}
+
+func TestDuplicatePaths(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr,
+		insert("Movies", 1, "/movies/Film"), insert("Movies", 2, "/Movies/film"), insert("Movies", 3, "/movies/Other"))
+
+	result, err := test.RunReport(config, report(t, starr.Radarr, "Duplicate Paths"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Rows) != 1 || result.Rows[0][1] != int64(2) {
+		t.Errorf("paths that differ by case should be duplicates: %v", result.Rows)
+	}
+}
+
+func TestReportsPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	// A folder is not inside another folder that starts with the same letters.
+	test, config := newDB(t, starr.Radarr, rootFolder("/movies"), rootFolder(`C:\Films\`),
+		insert("Movies", 1, "/movies/A"), insert("Movies", 2, "/movies2/B"),
+		insert("Movies", 3, `C:\Films\C`), insert("Movies", 4, `C:\Films2\D`), insert("Movies", 5, "/moviesE"))
+
+	result, err := test.RunReport(config, report(t, starr.Radarr, "Items Outside Root Folders"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []any{int64(2), int64(5), int64(4)} // sorted by path.
+	if len(result.Rows) != len(want) {
+		t.Fatalf("wrong items outside root folders: %v", result.Rows)
+	}
+
+	for idx, row := range result.Rows {
+		if row[0] != want[idx] {
+			t.Errorf("row %d: wanted %v, got: %v", idx, want[idx], row)
+		}
+	}
+
+	// Readarr files belong to an author by path.
+	test, config = newDB(t, starr.Readarr, insert("Authors", 1, "/books/A"), insert("Authors", 2, "/books/AB"),
+		insert("BookFiles", 1, "/books/A/one.epub"), insert("BookFiles", 2, "/books/AB/two.epub"))
+
+	if result, err = test.RunReport(config, report(t, starr.Readarr, "Largest Items"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range result.Rows {
+		if row[2] != int64(1) {
+			t.Errorf("each author has one file: %v", result.Rows)
+		}
+	}
+}
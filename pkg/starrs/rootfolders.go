@@ -1,8 +1,19 @@
 package starrs
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
 	"golift.io/starr"
 	"golift.io/starr/lidarr"
 	"golift.io/starr/radarr"
@@ -10,6 +21,13 @@ import (
 	"golift.io/starr/sonarr"
 )
 
+// Errors adding root folders.
+var (
+	ErrNoPath     = errors.New("a root folder path must be provided")
+	ErrNoProfiles = errors.New("no profiles found")
+	ErrBadFolder  = errors.New("folder is not inside the root folder")
+)
+
 func (s *Starrs) RootFolders(config *AppConfig) (any, error) {
 	s.log.Tracef("Call:RootFolders(%s, %s)", config.App, config.Name)
 
@@ -18,7 +36,7 @@ func (s *Starrs) RootFolders(config *AppConfig) (any, error) {
 		msg := s.log.Translate("Getting root folders: %v", err.Error())
 		s.log.Wails.Error(msg)
 
-		return nil, fmt.Errorf(msg)
+		return nil, errors.New(msg)
 	}
 
 	return list, nil
@@ -45,3 +63,417 @@ func (s *Starrs) rootFolders(config *AppConfig) (any, error) {
 		return nil, fmt.Errorf("%w: missing app", starr.ErrRequestError)
 	}
 }
+
+/* Root folder management through the API: space, item counts and unmapped folders, for every instance. */
+
+// UnmappedFolder is a folder inside a root folder that no library item uses.
+type UnmappedFolder struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// RootFolderInfo is a root folder with its disk space, item count and unmapped folders.
+type RootFolderInfo struct {
+	App        string
+	Instance   string
+	ID         int64             `json:"id"`
+	Path       string            `json:"path"`
+	Accessible bool              `json:"accessible"`
+	FreeSpace  int64             `json:"freeSpace"`
+	TotalSpace int64             `json:"totalSpace"`
+	Unmapped   []*UnmappedFolder `json:"unmappedFolders"`
+	Free       string            // formatted for humans.
+	Total      string            // formatted for humans.
+	Items      int               // Library items in this root folder.
+}
+
+// RootFolderMatrix is the root folders for every instance.
+type RootFolderMatrix struct {
+	Folders []*RootFolderInfo            // Sorted by app, instance and path.
+	Errors  map[string]map[string]string // App => instance name => error getting its root folders.
+	Elapsed string
+}
+
+// NewRootFolder is a root folder to add. Lidarr and Readarr root folders also have a name and
+// default profiles; the name defaults to the folder name and the profiles to the first ones.
+type NewRootFolder struct {
+	Path              string
+	Name              string
+	QualityProfileID  int64
+	MetadataProfileID int64
+}
+
+// UnmappedImport adds unmapped folders to the library. Each folder is looked up by its name.
+type UnmappedImport struct {
+	RootID            int64
+	Folders           []string // Folder names from the root folder's unmapped list.
+	QualityProfileID  int64
+	MetadataProfileID int64 // Lidarr and Readarr only.
+	Monitored         bool
+	Search            bool
+}
+
+// UnmappedResult is the result of importing or deleting one unmapped folder.
+type UnmappedResult struct {
+	Folder string
+	Title  string
+	OK     bool
+	Msg    string
+}
+
+// RootFolderDetails returns the root folders for an instance with their space, item counts and unmapped folders.
+func (s *Starrs) RootFolderDetails(config *AppConfig) ([]*RootFolderInfo, error) {
+	s.log.Tracef("Call:RootFolderDetails(%s, %s)", config.App, config.Name)
+
+	folders, err := s.rootFolderDetails(config)
+	if err != nil {
+		msg := s.log.Translate("Getting root folders: %v", err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return folders, nil
+}
+
+// RootFoldersAll returns the root folders for every provided instance, checked concurrently.
+func (s *Starrs) RootFoldersAll(instances Instances) *RootFolderMatrix {
+	s.log.Tracef("Call:RootFoldersAll(%d)", len(instances))
+
+	start := time.Now()
+	matrix := &RootFolderMatrix{Folders: []*RootFolderInfo{}, Errors: make(map[string]map[string]string)}
+	input := make(chan *AppConfig)
+	output := make(chan *RootFolderMatrix)
+	wait := sync.WaitGroup{}
+
+	for range healthWorkers {
+		wait.Add(1)
+
+		go func() {
+			defer s.log.CapturePanic()
+			defer wait.Done()
+
+			for config := range input {
+				folders, err := s.rootFolderDetails(config)
+				if err != nil {
+					output <- &RootFolderMatrix{Errors: map[string]map[string]string{config.App: {config.Name: err.Error()}}}
+				} else {
+					output <- &RootFolderMatrix{Folders: folders}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for app := range instances {
+			for idx := range instances[app] {
+				if getLibrary(instances[app][idx].App) != nil {
+					input <- &instances[app][idx]
+				}
+			}
+		}
+
+		close(input)
+		wait.Wait()
+		close(output)
+	}()
+
+	for result := range output {
+		matrix.Folders = append(matrix.Folders, result.Folders...)
+		for app, errs := range result.Errors {
+			if matrix.Errors[app] == nil {
+				matrix.Errors[app] = make(map[string]string)
+			}
+
+			maps.Copy(matrix.Errors[app], errs)
+		}
+	}
+
+	sort.Slice(matrix.Folders, func(i, j int) bool {
+		left, right := matrix.Folders[i], matrix.Folders[j]
+		if left.App != right.App {
+			return left.App < right.App
+		} else if left.Instance != right.Instance {
+			return left.Instance < right.Instance
+		}
+
+		return left.Path < right.Path
+	})
+
+	matrix.Elapsed = time.Since(start).Round(time.Millisecond).String()
+
+	return matrix
+}
+
+func (s *Starrs) rootFolderDetails(config *AppConfig) ([]*RootFolderInfo, error) {
+	if getLibrary(config.App) == nil {
+		return nil, fmt.Errorf("%w: %s has no root folders", ErrInvalidApp, config.App)
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The starr library's root folders have no total space, and Readarr's have no unmapped folders.
+	folders := []*RootFolderInfo{}
+	if err := instance.getInto(s.ctx, "rootfolder", &folders); err != nil {
+		return nil, err
+	}
+
+	items, err := instance.library()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(folders))
+	for idx, folder := range folders {
+		paths[idx] = folder.Path
+	}
+
+	for _, item := range items {
+		if dirs, ok := itemInRoot(item.Path(), paths); ok {
+			folders[slices.Index(paths, longestRoot(dirs))].Items++
+		}
+	}
+
+	for _, folder := range folders {
+		folder.App, folder.Instance = config.App, config.Name
+		folder.Free = mnd.FormatBytes(max(folder.FreeSpace, 0))
+		folder.Total = mnd.FormatBytes(max(folder.TotalSpace, 0))
+
+		if folder.Unmapped == nil {
+			folder.Unmapped = []*UnmappedFolder{}
+		}
+	}
+
+	return folders, nil
+}
+
+// AddRootFolder adds a root folder to an instance.
+func (s *Starrs) AddRootFolder(config *AppConfig, folder *NewRootFolder) (*DataReply, error) {
+	s.log.Tracef("Call:AddRootFolder(%s, %s, %s)", config.App, config.Name, folder.Path)
+
+	added, err := s.addRootFolder(config, folder)
+	if err != nil {
+		msg := s.log.Translate("Adding %s root folder %s: %v", config.Name, folder.Path, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &DataReply{Msg: s.log.Translate("Added %s root folder %s.", config.Name, folder.Path), Data: added}, nil
+}
+
+func (s *Starrs) addRootFolder(config *AppConfig, folder *NewRootFolder) (LibraryItem, error) {
+	lib := getLibrary(config.App)
+	if lib == nil {
+		return nil, fmt.Errorf("%w: %s has no root folders", ErrInvalidApp, config.App)
+	} else if strings.TrimSpace(folder.Path) == "" {
+		return nil, ErrNoPath
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	root := LibraryItem{"path": folder.Path}
+
+	// Lidarr and Readarr root folders have a name and default profiles.
+	if _, ok := lib.Profiles["metadataProfileId"]; ok {
+		root["name"] = folder.Name
+		if folder.Name == "" {
+			root["name"] = path.Base(strings.ReplaceAll(strings.TrimRight(folder.Path, `/\`), `\`, "/"))
+		}
+
+		root["defaultQualityProfileId"], err = instance.profileID(qualityProfile, folder.QualityProfileID)
+		if err != nil {
+			return nil, err
+		}
+
+		root["defaultMetadataProfileId"], err = instance.profileID(metadataProfile, folder.MetadataProfileID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance.postRootFolder(root)
+}
+
+// profileID returns the provided profile id, or the first profile's id when it's 0.
+func (i *instance) profileID(kind string, profileID int64) (int64, error) {
+	if profileID != 0 {
+		return profileID, nil
+	}
+
+	profiles, err := i.profiles(kind)
+	if err != nil {
+		return 0, err
+	} else if len(profiles) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoProfiles, kind)
+	}
+
+	return profiles[0].ID(), nil
+}
+
+// RemoveRootFolder deletes a root folder from an instance with its API. Items and files are not changed.
+func (s *Starrs) RemoveRootFolder(config *AppConfig, folder *RootFolderInfo) (*DataReply, error) {
+	s.log.Tracef("Call:RemoveRootFolder(%s, %s, %s)", config.App, config.Name, folder.Path)
+
+	question := s.log.Translate("Really delete %s root folder?\nPath: %s\nItems in this root folder: %d",
+		config.Name, folder.Path, folder.Items)
+	if !s.app.Ask(s.log.Translate("Delete Root Folder"), question) {
+		return &DataReply{}, nil
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := instance.removeRootFolder(folder.ID); err != nil {
+		msg := s.log.Translate("Deleting %s root folder %s: %v", config.Name, folder.Path, err.Error())
+		s.log.Wails.Error(msg)
+
+		return nil, errors.New(msg)
+	}
+
+	return &DataReply{Msg: s.log.Translate("Deleted %s root folder %s.", config.Name, folder.Path)}, nil
+}
+
+// ImportUnmapped adds unmapped folders to the library. Each folder name is looked up with the
+// app's search, and the first result is added with the folder as its path.
+func (s *Starrs) ImportUnmapped(config *AppConfig, input *UnmappedImport) ([]*UnmappedResult, error) {
+	s.log.Tracef("Call:ImportUnmapped(%s, %s, %d folders)", config.App, config.Name, len(input.Folders))
+
+	instance, root, err := s.unmappedRoot(config, input.RootID, input.Folders)
+	if err != nil {
+		return nil, err
+	}
+
+	lib := getLibrary(config.App)
+	results := []*UnmappedResult{}
+
+	for _, folder := range input.Folders {
+		result := &UnmappedResult{Folder: folder}
+		results = append(results, result)
+
+		item, err := instance.lookup(folder)
+		if err != nil {
+			result.Msg = err.Error()
+			continue
+		}
+
+		result.Title = item.String(lib.Title)
+		item["path"] = trailingSlash(root.Path) + folder
+		item["rootFolderPath"] = root.Path
+		item["monitored"] = input.Monitored
+		item["qualityProfileId"] = input.QualityProfileID
+		item["addOptions"] = map[string]any{lib.Search: input.Search}
+
+		if _, ok := lib.Profiles["metadataProfileId"]; ok {
+			item["metadataProfileId"] = input.MetadataProfileID
+		}
+
+		if _, err := instance.addItem(item); err != nil {
+			result.Msg = err.Error()
+		} else {
+			result.OK, result.Msg = true, s.log.Translate("Added %s.", result.Title)
+		}
+
+		s.app.Emit("UnmappedProgress", result)
+	}
+
+	return results, nil
+}
+
+// DeleteUnmapped deletes unmapped folders and everything in them from this computer's disk.
+// Folders must be in the root folder's unmapped list, and reachable at the same path here.
+// Folder names with path separators, and folders that resolve outside the root folder, are rejected.
+func (s *Starrs) DeleteUnmapped(config *AppConfig, rootID int64, folders []string) ([]*UnmappedResult, error) {
+	s.log.Tracef("Call:DeleteUnmapped(%s, %s, %d folders)", config.App, config.Name, len(folders))
+
+	_, root, err := s.unmappedRoot(config, rootID, folders)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, folder := range folders {
+		if folder == "" || folder == "." || folder == ".." || strings.ContainsAny(folder, `/\`) {
+			return nil, errors.New(s.log.Translate("%s is not a folder name; it cannot be deleted.", folder))
+		}
+	}
+
+	question := s.log.Translate("Really delete %d folders, and every file in them, from %s?\n"+
+		"This Action cannot be undone, and this application does not make backups.", len(folders), root.Path)
+	if !s.app.Ask(s.log.Translate("Delete Unmapped Folders"), question) {
+		return []*UnmappedResult{}, nil
+	}
+
+	results := []*UnmappedResult{}
+
+	for _, folder := range folders {
+		result := &UnmappedResult{Folder: folder, Title: filepath.Join(root.Path, folder)}
+		results = append(results, result)
+
+		if _, err := os.Stat(result.Title); err != nil {
+			result.Msg = s.log.Translate("Folder not found on this computer: %v", err.Error())
+		} else if err := inFolder(root.Path, result.Title); err != nil {
+			result.Msg = err.Error()
+		} else if err := os.RemoveAll(result.Title); err != nil {
+			result.Msg = err.Error()
+		} else {
+			result.OK, result.Msg = true, s.log.Translate("Deleted %s.", result.Title)
+		}
+	}
+
+	return results, nil
+}
+
+// inFolder returns an error if a path, with links resolved, is not inside a folder.
+func inFolder(folder, path string) error {
+	folder, err := filepath.EvalSymlinks(folder)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", folder, err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(folder, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s => %s", ErrBadFolder, path, resolved)
+	}
+
+	return nil
+}
+
+// unmappedRoot returns a root folder, and checks that every folder is in its unmapped list.
+func (s *Starrs) unmappedRoot(config *AppConfig, rootID int64, folders []string) (*instance, *RootFolderInfo, error) {
+	if len(folders) == 0 {
+		return nil, nil, errors.New(s.log.Translate("Select folders first."))
+	}
+
+	list, err := s.RootFolderDetails(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := slices.IndexFunc(list, func(root *RootFolderInfo) bool { return root.ID == rootID })
+	if idx < 0 {
+		return nil, nil, errors.New(s.log.Translate("%s has no root folder with ID %d.", config.Name, rootID))
+	}
+
+	for _, folder := range folders {
+		if !slices.ContainsFunc(list[idx].Unmapped, func(dir *UnmappedFolder) bool { return dir.Name == folder }) {
+			return nil, nil, errors.New(s.log.Translate("%s is not an unmapped folder in %s.", folder, list[idx].Path))
+		}
+	}
+
+	instance, err := s.newAPIinstance(config)
+
+	return instance, list[idx], err
+}
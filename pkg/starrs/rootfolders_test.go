@@ -0,0 +1,185 @@
+package starrs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestRootFolderDetails(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Sonarr)
+	defer server.Close()
+
+	server.Seed("rootfolder",
+		starrtest.Item{"id": 1, "path": "/tv", "accessible": true, "freeSpace": 1024, "totalSpace": 4096,
+			"unmappedFolders": []starrtest.Item{{"name": "Stray", "path": "/tv/Stray"}}},
+		starrtest.Item{"id": 2, "path": "/tv/kids/"},
+	)
+	server.Seed("series",
+		starrtest.Item{"id": 3, "path": "/tv/A"},
+		starrtest.Item{"id": 4, "path": "/tv/kids/B"},
+		starrtest.Item{"id": 5, "path": "/tv/kids/C"},
+		starrtest.Item{"id": 6, "path": "/other/D"},
+	)
+
+	folders, err := test.RootFolderDetails(newConfig(server))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(folders) != 2 || folders[0].Items != 1 || folders[1].Items != 2 {
+		t.Fatalf("items should count in the longest matching root folder: %+v", folders)
+	}
+
+	if folders[0].Free != "1.0 KiB" || len(folders[0].Unmapped) != 1 || folders[1].Unmapped == nil {
+		t.Errorf("wrong space or unmapped folders: %+v", folders[0])
+	}
+
+	broken := AppConfig{App: starr.Radarr.String(), Name: "broken", URL: "http://127.0.0.1:1/", Key: starrtest.APIKey,
+		Timeout: time.Second}
+	brokenToo := broken
+	brokenToo.App = starr.Lidarr.String()
+	matrix := test.RootFoldersAll(Instances{
+		starr.Sonarr.String():   {*newConfig(server)},
+		starr.Radarr.String():   {broken},
+		starr.Lidarr.String():   {brokenToo},
+		starr.Prowlarr.String(): {{App: starr.Prowlarr.String(), Name: "prowlarr"}},
+	})
+
+	// Instances in different apps may have the same name.
+	if len(matrix.Folders) != 2 || len(matrix.Errors) != 2 ||
+		matrix.Errors[starr.Radarr.String()]["broken"] == "" || matrix.Errors[starr.Lidarr.String()]["broken"] == "" {
+		t.Errorf("wrong root folders for every instance: %+v", matrix)
+	}
+}
+
+func TestAddRemoveRootFolder(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Lidarr)
+	defer server.Close()
+
+	server.Seed("qualityprofile", starrtest.Item{"id": 4, "name": "Lossless"})
+	server.Seed("metadataprofile", starrtest.Item{"id": 5, "name": "Standard"})
+	config := newConfig(server)
+
+	if _, err := test.AddRootFolder(config, &NewRootFolder{Path: "/music/lossless/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := server.Items("rootfolder")
+	if len(roots) != 1 || roots[0]["name"] != "lossless" || roots[0]["defaultQualityProfileId"] != float64(4) ||
+		roots[0]["defaultMetadataProfileId"] != float64(5) {
+		t.Fatalf("the root folder should have a name and default profiles: %v", roots)
+	}
+
+	if _, err := test.AddRootFolder(config, &NewRootFolder{}); err == nil {
+		t.Error("adding a root folder without a path should return an error")
+	}
+
+	if _, err := test.RemoveRootFolder(config, &RootFolderInfo{ID: roots[0].ID(), Path: "/music/lossless/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.Items("rootfolder")) != 0 {
+		t.Error("the root folder should be deleted")
+	}
+}
+
+func TestUnmappedFolders(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "Stray"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Seed("rootfolder", starrtest.Item{"id": 1, "path": dir, "unmappedFolders": []starrtest.Item{
+		{"name": "Film (2020)", "path": filepath.Join(dir, "Film (2020)")},
+		{"name": "Unknown", "path": filepath.Join(dir, "Unknown")},
+		{"name": "Stray", "path": filepath.Join(dir, "Stray")},
+	}})
+	server.Seed("movie/lookup", starrtest.Item{"title": "Film (2020)", "tmdbId": 7})
+	config := newConfig(server)
+
+	results, err := test.ImportUnmapped(config, &UnmappedImport{
+		RootID: 1, Folders: []string{"Film (2020)", "Unknown"}, QualityProfileID: 3, Monitored: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || !results[0].OK || results[1].OK {
+		t.Errorf("only the folder with a lookup result should be imported: %+v, %+v", results[0], results[1])
+	}
+
+	movies := server.Items("movie")
+	if len(movies) != 1 || movies[0]["path"] != filepath.Join(dir, "Film (2020)") ||
+		movies[0]["qualityProfileId"] != float64(3) || movies[0]["tmdbId"] != float64(7) {
+		t.Errorf("the movie should be added in the unmapped folder: %v", movies)
+	}
+
+	if _, err := test.ImportUnmapped(config, &UnmappedImport{RootID: 1, Folders: []string{"Missing"}}); err == nil {
+		t.Error("importing a folder that is not unmapped should return an error")
+	}
+
+	results, err = test.DeleteUnmapped(config, 1, []string{"Stray", "Unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !results[0].OK || results[1].OK {
+		t.Errorf("only the folder on disk should be deleted: %+v, %+v", results[0], results[1])
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "Stray")); !os.IsNotExist(err) {
+		t.Errorf("the unmapped folder should be deleted: %v", err)
+	}
+}
+
+func TestDeleteUnmappedOutside(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	dir, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "Link")); err != nil {
+		t.Skip("symlinks are not available:", err)
+	}
+
+	server.Seed("rootfolder", starrtest.Item{"id": 1, "path": dir, "unmappedFolders": []starrtest.Item{
+		{"name": "..", "path": filepath.Dir(dir)},
+		{"name": "../" + filepath.Base(outside), "path": outside},
+		{"name": "Link", "path": filepath.Join(dir, "Link")},
+	}})
+	config := newConfig(server)
+
+	for _, folder := range []string{"..", "../" + filepath.Base(outside)} {
+		if _, err := test.DeleteUnmapped(config, 1, []string{folder}); err == nil {
+			t.Errorf("%s is not a folder name, and should return an error", folder)
+		}
+	}
+
+	results, err := test.DeleteUnmapped(config, 1, []string{"Link"})
+	if err != nil || len(results) != 1 || results[0].OK {
+		t.Errorf("a link to a folder outside the root folder should not be deleted: %+v, %v", results, err)
+	}
+
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("the folder outside the root folder was deleted: %v", err)
+	}
+}
@@ -0,0 +1,401 @@
+package starrs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* Move a root folder through the API, for users that cannot reach the database. */
+
+// Root folder move steps, in order.
+const (
+	MoveAddRoot    = "add root folder"
+	MoveItems      = "move items"
+	MoveLists      = "update import lists"
+	MoveDeleteRoot = "delete old root folder"
+	MoveDone       = "done"
+)
+
+// moveBatch is how many items are sent to the editor at once.
+const moveBatch = 100
+
+// RootMove is the state of a root folder move. Every step checks the app's current data,
+// so running an interrupted move again picks up where it stopped.
+type RootMove struct {
+	From      string
+	To        string
+	DeleteOld bool
+	Step      string
+	Items     int // Items in the old root folder when the move started.
+	Moved     int // Items moved so far.
+	Lists     int // Import lists updated.
+	Resumed   bool
+	Msg       string
+	Error     string
+}
+
+// MoveRootFolder moves every item from one root folder to another with the app's API,
+// like UpdateRootFolder does in the database. Files are not moved. The new root folder is
+// added, items and import lists are pointed at it, and the old root folder is deleted if
+// deleteOld is true. Emits RootMoveProgress after each step and batch of items.
+func (s *Starrs) MoveRootFolder(config *AppConfig, from, to string, deleteOld bool) (*RootMove, error) {
+	s.log.Tracef("Call:MoveRootFolder(%s, %s, %s => %s)", config.App, config.Name, from, to)
+
+	if getLibrary(config.App) == nil {
+		return nil, errors.New(s.log.Translate("%s has no root folders.", config.App))
+	} else if from = strings.TrimSpace(from); from == "" || strings.TrimSpace(to) == "" {
+		return nil, errors.New(s.log.Translate("Provide the old and the new root folder."))
+	} else if trailingSlash(from) == trailingSlash(to) {
+		return nil, errors.New(s.log.Translate("The old and new root folders are the same."))
+	}
+
+	move := &RootMove{From: from, To: to, DeleteOld: deleteOld, Step: MoveAddRoot}
+	if last := s.RootMoveState(config); last != nil && last.Step != MoveDone &&
+		last.From == from && last.To == to {
+		move.Resumed = true
+	}
+
+	question := s.log.Translate("Move every item in %s from %s to %s? Files are not moved.", config.Name, from, to)
+	if !s.app.Ask(s.log.Translate("Move Root Folder"), question) {
+		return &RootMove{}, nil
+	}
+
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.moveProgress(move)
+
+	if err := instance.moveRootFolder(move); err != nil {
+		move.Error = err.Error()
+		move.Msg = s.log.Translate("Moving root folder stopped at step '%s': %v. Run the move again to resume.",
+			move.Step, err.Error())
+		s.log.Wails.Error(move.Msg)
+		instance.moveProgress(move)
+
+		return nil, errors.New(move.Msg)
+	}
+
+	move.Msg = s.log.Translate("Moved %d items and %d import lists from %s to %s.", move.Moved, move.Lists, from, to)
+	instance.moveProgress(move)
+
+	return move, nil
+}
+
+// RootMoveState returns a copy of the last root folder move for an instance, or nil if there has not been one.
+func (s *Starrs) RootMoveState(config *AppConfig) *RootMove {
+	s.log.Tracef("Call:RootMoveState(%s, %s)", config.App, config.Name)
+
+	s.movesMu.Lock()
+	defer s.movesMu.Unlock()
+
+	if move, ok := s.moves[moveKey(config)]; ok {
+		saved := *move
+		return &saved
+	}
+
+	return nil
+}
+
+// LoadRootMoves sets the file root folder moves are saved in, and reads the moves already in it.
+// Call this after Startup. Without a file, moves are only kept until the app exits.
+func LoadRootMoves(starrs *Starrs, path string) error {
+	moves := make(map[string]*RootMove)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading root folder moves: %w", err)
+	} else if err == nil {
+		if err := json.Unmarshal(data, &moves); err != nil {
+			return fmt.Errorf("decoding root folder moves: %w", err)
+		}
+	}
+
+	starrs.movesMu.Lock()
+	defer starrs.movesMu.Unlock()
+
+	starrs.moves = moves
+	starrs.movesFile = path
+
+	return nil
+}
+
+// moveKey identifies an instance's root folder move. Instances in different apps may have the same name.
+func moveKey(config *AppConfig) string {
+	return config.App + "/" + config.Name
+}
+
+// moveProgress saves a copy of the move, writes the moves file, and tells the frontend.
+// The mover keeps its own copy, so RootMoveState never reads a move while it changes.
+func (i *instance) moveProgress(move *RootMove) {
+	saved := *move
+	defer i.app.Emit("RootMoveProgress", &saved)
+
+	i.movesMu.Lock()
+	defer i.movesMu.Unlock()
+
+	if i.moves == nil {
+		i.moves = make(map[string]*RootMove)
+	}
+
+	i.moves[moveKey(i.config)] = &saved
+
+	if i.movesFile == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(i.moves, "", " ")
+	if err == nil {
+		err = mnd.WriteFile(i.movesFile, data)
+	}
+
+	if err != nil {
+		i.log.Errorf("Saving root folder moves: %v", err)
+	}
+}
+
+// moveRootFolder runs each step of a root folder move.
+func (i *instance) moveRootFolder(move *RootMove) error {
+	roots, err := i.moveRoots()
+	if err != nil {
+		return err
+	}
+
+	oldRoot, newRoot := findRoot(roots, move.From), findRoot(roots, move.To)
+	if newRoot == nil {
+		if err := i.addRoot(move, oldRoot); err != nil {
+			return err
+		}
+	}
+
+	move.Step = MoveItems
+	i.moveProgress(move)
+
+	if err := i.moveItems(move); err != nil {
+		return err
+	}
+
+	move.Step = MoveLists
+	i.moveProgress(move)
+
+	if err := i.moveLists(move); err != nil {
+		return err
+	}
+
+	if move.DeleteOld && oldRoot != nil {
+		move.Step = MoveDeleteRoot
+		i.moveProgress(move)
+
+		if err := i.removeRootFolder(oldRoot.ID()); err != nil {
+			return err
+		}
+	}
+
+	move.Step = MoveDone
+
+	return nil
+}
+
+// addRoot adds the new root folder. Lidarr and Readarr root folders have a name and
+// default profiles; those are copied from the old root folder.
+func (i *instance) addRoot(move *RootMove, oldRoot LibraryItem) error {
+	root := LibraryItem{}
+	if oldRoot != nil {
+		root = oldRoot.copy()
+		delete(root, "id")
+		delete(root, "freeSpace")
+		delete(root, "totalSpace")
+		delete(root, "unmappedFolders")
+	}
+
+	root["path"] = move.To
+	if _, ok := root["name"]; ok {
+		root["name"] = path.Base(strings.ReplaceAll(strings.TrimRight(move.To, `/\`), `\`, "/"))
+	}
+
+	_, err := i.postRootFolder(root)
+
+	return err
+}
+
+// moveRoots returns the root folders. Lidarr and Readarr root folders are read without the starr
+// library, because it lacks fields the new root folder copies, like the name and default profiles.
+func (i *instance) moveRoots() ([]LibraryItem, error) {
+	switch starr.App(i.config.App) {
+	case starr.Lidarr, starr.Readarr:
+		roots := []LibraryItem{}
+		if err := i.getInto(i.ctx, "rootfolder", &roots); err != nil {
+			return nil, err
+		}
+
+		return roots, nil
+	default:
+		list, err := i.rootFolders(i.config)
+		if err != nil {
+			return nil, err
+		}
+
+		return toItems(list)
+	}
+}
+
+// postRootFolder adds a root folder, and returns it.
+func (i *instance) postRootFolder(root LibraryItem) (LibraryItem, error) {
+	var (
+		added any
+		err   error
+		item  = LibraryItem{}
+	)
+
+	switch starr.App(i.config.App) {
+	case starr.Radarr:
+		added, err = radarr.New(i.Config).AddRootFolderContext(i.ctx, &radarr.RootFolder{Path: root.Path()})
+	case starr.Sonarr, starr.Whisparr:
+		added, err = sonarr.New(i.Config).AddRootFolderContext(i.ctx, &sonarr.RootFolder{Path: root.Path()})
+	default:
+		// The starr library cannot add lidarr and readarr root folders.
+		if err := i.postInto(i.ctx, "rootfolder", root, &item); err != nil {
+			return nil, err
+		}
+
+		return item, nil
+	}
+
+	if err != nil {
+		return nil, err
+	} else if err = convert(added, &item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// removeRootFolder deletes a root folder. Items and files are not changed.
+func (i *instance) removeRootFolder(rootID int64) error {
+	switch starr.App(i.config.App) {
+	case starr.Radarr:
+		return radarr.New(i.Config).DeleteRootFolderContext(i.ctx, rootID)
+	case starr.Sonarr, starr.Whisparr:
+		return sonarr.New(i.Config).DeleteRootFolderContext(i.ctx, rootID)
+	default:
+		// The starr library cannot delete lidarr and readarr root folders.
+		return i.deleteAny(i.ctx, "rootfolder/"+strconv.FormatInt(rootID, 10), nil)
+	}
+}
+
+// moveItems points the items in the old root folder at the new one, in batches.
+func (i *instance) moveItems(move *RootMove) error {
+	items, err := i.library()
+	if err != nil {
+		return err
+	}
+
+	ids := []int64{}
+
+	for _, item := range items {
+		if _, ok := itemInRoot(item.Path(), []string{move.From}); ok {
+			ids = append(ids, item.ID())
+		}
+	}
+
+	move.Items, move.Moved = len(ids), 0
+	lib := getLibrary(i.config.App)
+
+	for start := 0; start < len(ids); start += moveBatch {
+		batch := ids[start:min(start+moveBatch, len(ids))]
+		body := map[string]any{lib.IDs: batch, "rootFolderPath": move.To, "moveFiles": false}
+
+		if _, err := i.editItems(body); err != nil {
+			return err
+		}
+
+		move.Moved += len(batch)
+		i.moveProgress(move)
+	}
+
+	return nil
+}
+
+// moveLists points the import lists that add items to the old root folder at the new one.
+func (i *instance) moveLists(move *RootMove) error {
+	list, err := i.importList(i.config)
+	if err != nil {
+		return err
+	}
+
+	lists, err := toItems(list)
+	if err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		if trailingSlash(list.String("rootFolderPath")) != trailingSlash(move.From) {
+			continue
+		}
+
+		list["rootFolderPath"] = move.To
+		if err := i.saveImportList(list); err != nil {
+			return err
+		}
+
+		move.Lists++
+	}
+
+	return nil
+}
+
+// saveImportList updates an import list with the starr library's input for the app.
+func (i *instance) saveImportList(list LibraryItem) error {
+	var err error
+
+	switch starr.App(i.config.App) {
+	case starr.Lidarr:
+		input := &lidarr.ImportListInput{}
+		if err = convert(list, input); err == nil {
+			_, err = lidarr.New(i.Config).UpdateImportListContext(i.ctx, input, false)
+		}
+	case starr.Radarr:
+		input := &radarr.ImportListInput{}
+		if err = convert(list, input); err == nil {
+			_, err = radarr.New(i.Config).UpdateImportListContext(i.ctx, input, false)
+		}
+	case starr.Readarr:
+		input := &readarr.ImportListInput{}
+		if err = convert(list, input); err == nil {
+			_, err = readarr.New(i.Config).UpdateImportListContext(i.ctx, input, false)
+		}
+	case starr.Sonarr, starr.Whisparr:
+		input := &sonarr.ImportListInput{}
+		if err = convert(list, input); err == nil {
+			_, err = sonarr.New(i.Config).UpdateImportListContext(i.ctx, input, false)
+		}
+	default:
+		err = fmt.Errorf("%w: %s has no import lists", ErrInvalidApp, i.config.App)
+	}
+
+	return err
+}
+
+// findRoot returns the root folder with a path, or nil.
+func findRoot(roots []LibraryItem, folder string) LibraryItem {
+	for _, root := range roots {
+		if trailingSlash(root.Path()) == trailingSlash(folder) {
+			return root
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package starrs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestMoveRootFolder(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	server.Seed("rootfolder", starrtest.Item{"id": 1, "path": "/movies/"})
+	server.Seed("movie",
+		starrtest.Item{"id": 2, "path": "/movies/A"},
+		starrtest.Item{"id": 3, "path": "/movies/B"},
+		starrtest.Item{"id": 4, "path": "/movies4k/C"},
+	)
+	server.Seed("importlist",
+		starrtest.Item{"id": 5, "name": "moved", "rootFolderPath": "/movies"},
+		starrtest.Item{"id": 6, "name": "kept", "rootFolderPath": "/movies4k"},
+	)
+
+	config := newConfig(server)
+
+	if _, err := test.MoveRootFolder(config, "/movies", "/movies/", false); err == nil {
+		t.Error("moving a root folder to itself should return an error")
+	}
+
+	move, err := test.MoveRootFolder(config, "/movies", "/data/movies", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if move.Step != MoveDone || move.Items != 2 || move.Moved != 2 || move.Lists != 1 || move.Resumed {
+		t.Errorf("wrong move state: %+v", move)
+	}
+
+	roots := server.Items("rootfolder")
+	if len(roots) != 1 || roots[0]["path"] != "/data/movies" {
+		t.Errorf("the new root folder should replace the old one: %v", roots)
+	}
+
+	for idx, want := range []string{"/data/movies/A", "/data/movies/B", "/movies4k/C"} {
+		if got := server.Items("movie")[idx]["path"]; got != want {
+			t.Errorf("wanted path %s, got: %v", want, got)
+		}
+	}
+
+	lists := server.Items("importlist")
+	if lists[0]["rootFolderPath"] != "/data/movies" || lists[1]["rootFolderPath"] != "/movies4k" {
+		t.Errorf("only the import list in the old root folder should change: %v", lists)
+	}
+
+	if state := test.RootMoveState(config); state == nil || *state != *move || state == move {
+		t.Errorf("a copy of the last move should be saved: %+v", state)
+	}
+
+	// Another app's instance with the same name has its own moves.
+	other := *config
+	other.App = starr.Sonarr.String()
+
+	if state := test.RootMoveState(&other); state != nil {
+		t.Errorf("moves should be saved for each app: %+v", state)
+	}
+
+	// Running it again finds nothing left to do.
+	if move, err = test.MoveRootFolder(config, "/movies", "/data/movies", true); err != nil || move.Moved != 0 {
+		t.Errorf("a finished move should have nothing left to do: %+v, %v", move, err)
+	}
+}
+
+func TestMoveRootFolderResume(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+	server := starrtest.New(starr.Lidarr)
+	defer server.Close()
+
+	server.Seed("rootfolder", starrtest.Item{"id": 1, "path": "/music", "name": "Music", "defaultQualityProfileId": 2})
+	server.Seed("artist", starrtest.Item{"id": 2, "path": "/music/A"})
+
+	config := newConfig(server)
+	movesFile := filepath.Join(t.TempDir(), "rootmoves.json")
+
+	if err := LoadRootMoves(test.Starrs, movesFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.ShutdownInstance(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.MoveRootFolder(config, "/music", "/data/music", false); err == nil {
+		t.Fatal("a stopped app should fail the move")
+	}
+
+	if state := test.RootMoveState(config); state == nil || state.Error == "" || state.Step != MoveAddRoot {
+		t.Fatalf("the failed move should be saved: %+v", state)
+	}
+
+	// Resume after a restart: the failed move is read from the file.
+	test = newTester(t)
+	if err := LoadRootMoves(test.Starrs, movesFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := test.RootMoveState(config); state == nil || state.Error == "" || state.From != "/music" {
+		t.Fatalf("the failed move should be read from the file: %+v", state)
+	}
+
+	// Point the config at a running app with the same data to resume.
+	restarted := starrtest.New(starr.Lidarr)
+	defer restarted.Close()
+
+	restarted.Seed("rootfolder", starrtest.Item{"id": 1, "path": "/music", "name": "Music", "defaultQualityProfileId": 2})
+	restarted.Seed("artist", starrtest.Item{"id": 2, "path": "/music/A"})
+	config.URL = restarted.URL()
+
+	move, err := test.MoveRootFolder(config, "/music", "/data/music", false)
+	if err != nil || !move.Resumed || move.Moved != 1 {
+		t.Fatalf("the move should resume: %+v, %v", move, err)
+	}
+
+	roots := restarted.Items("rootfolder")
+	if len(roots) != 2 || roots[1]["name"] != "music" || roots[1]["defaultQualityProfileId"] != float64(2) {
+		t.Errorf("the new root folder should copy the old one's settings: %v", roots)
+	}
+}
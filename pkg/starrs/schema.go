@@ -0,0 +1,171 @@
+package starrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golift.io/starr"
+)
+
+/* Checks that a database schema matches the tables and columns the migrator writes to. */
+
+// SchemaRange is a range of database migration versions from the VersionInfo table.
+type SchemaRange struct {
+	Min int64
+	Max int64
+}
+
+// SchemaCheck is the result of comparing a database to the tables in AppTables.
+type SchemaCheck struct {
+	Version int64       // Newest migration in the database.
+	Range   SchemaRange // Migrations toolbarr knows how to write to.
+	Known   bool        // Version is inside Range.
+	Missing []string    // Table.Column pairs the migrator uses that the database does not have.
+}
+
+// Known migration versions for each app. The versions are the numbered files in each app's
+// src/NzbDrone.Core/Datastore/Migration folder, and FluentMigrator writes them to the VersionInfo table:
+//   - https://github.com/Lidarr/Lidarr/tree/develop/src/NzbDrone.Core/Datastore/Migration
+//   - https://github.com/Radarr/Radarr/tree/develop/src/NzbDrone.Core/Datastore/Migration
+//   - https://github.com/Readarr/Readarr/tree/develop/src/NzbDrone.Core/Datastore/Migration
+//   - https://github.com/Sonarr/Sonarr/tree/develop/src/NzbDrone.Core/Datastore/Migration
+//
+// Whisparr is not listed. Its migrations have not been checked against the tables in AppTables,
+// so every Whisparr version is unknown, and the user is asked before writing.
+const (
+	lidarrSchemaMin  = 50  // Lidarr 1.x.
+	lidarrSchemaMax  = 80  // Current Lidarr releases.
+	radarrSchemaMin  = 200 // Radarr v4.
+	radarrSchemaMax  = 245 // Radarr v5.
+	readarrSchemaMin = 25  // Readarr 0.1.
+	readarrSchemaMax = 45  // Current Readarr releases.
+	sonarrSchemaMin  = 150 // Sonarr v3.
+	sonarrSchemaMax  = 215 // Sonarr v4.
+)
+
+// SchemaRanges returns the known-compatible migration versions for an app.
+// Newer releases often add migrations that do not touch these tables, so an unknown version
+// asks before writing. Missing columns always stop a write. The range is empty for unknown apps.
+func SchemaRanges(app string) SchemaRange {
+	switch starr.App(app) {
+	case starr.Lidarr:
+		return SchemaRange{Min: lidarrSchemaMin, Max: lidarrSchemaMax}
+	case starr.Radarr:
+		return SchemaRange{Min: radarrSchemaMin, Max: radarrSchemaMax}
+	case starr.Readarr:
+		return SchemaRange{Min: readarrSchemaMin, Max: readarrSchemaMax}
+	case starr.Sonarr:
+		return SchemaRange{Min: sonarrSchemaMin, Max: sonarrSchemaMax}
+	default:
+		return SchemaRange{}
+	}
+}
+
+// CheckSchema compares a database's migration version and columns to what the migrator writes.
+func (s *Starrs) CheckSchema(config *AppConfig) (*SchemaCheck, error) {
+	s.log.Tracef("Call:CheckSchema(%s, %s)", config.App, config.Name)
+
+	sql, err := s.newSQL(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sql.Close()
+
+	check, err := s.checkSchema(sql, config)
+	if err != nil {
+		return nil, errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	}
+
+	return check, nil
+}
+
+func (s *Starrs) checkSchema(sql *sqlConn, config *AppConfig) (*SchemaCheck, error) {
+	check := &SchemaCheck{Range: SchemaRanges(config.App), Missing: []string{}}
+
+	var err error
+	if check.Version, err = sql.SchemaVersion(s.ctx); err != nil {
+		return nil, err
+	}
+
+	check.Known = check.Range.Max > 0 && check.Version >= check.Range.Min && check.Version <= check.Range.Max
+	// The migrator also writes to these tables for every app.
+	used := map[string][]string{"RootFolders": {"Id", "Path"}, "Config": {"Key", "Value"}}
+
+	for _, table := range AppTables(config.App) {
+		used[table.Table] = append(used[table.Table], "Id", table.Column)
+		if table.Name != `""` {
+			used[table.Table] = append(used[table.Table], table.Name)
+		}
+	}
+
+	for table, columns := range used {
+		have, err := sql.Columns(s.ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, column := range columns {
+			if !slices.Contains(have, column) {
+				check.Missing = append(check.Missing, table+"."+column)
+			}
+		}
+	}
+
+	slices.Sort(check.Missing)
+
+	return check, nil
+}
+
+// guardSchema is called before the migrator writes to a database. It returns an error if
+// columns are missing. If the migration version is unknown, the user may write anyway.
+func (s *Starrs) guardSchema(config *AppConfig) error {
+	sql, err := s.newSQL(config)
+	if err != nil {
+		return err
+	}
+	defer sql.Close()
+
+	check, err := s.checkSchema(sql, config)
+	if err != nil {
+		return errors.New(s.log.Translate("Querying Sqlite3 DB: %v", err.Error()))
+	}
+
+	if len(check.Missing) > 0 {
+		return errors.New(s.log.Translate("%s database schema version %d is not compatible. Missing columns: %s",
+			config.Name, check.Version, strings.Join(check.Missing, ", ")))
+	}
+
+	if check.Known {
+		return nil
+	}
+
+	known := s.log.Translate("No %s versions are known.", config.App)
+	if check.Range.Max > 0 {
+		known = s.log.Translate("Known versions for %s are %d to %d.", config.App, check.Range.Min, check.Range.Max)
+	}
+
+	question := s.log.Translate("%s database schema version %d is unknown. %s\n"+
+		"The tables look right, but this version has not been tested. Write anyway?",
+		config.Name, check.Version, known)
+	if !s.app.Ask(s.log.Translate("Unknown Database Schema"), question) {
+		return errors.New(s.log.Translate("Canceled: %s database schema version %d is unknown.",
+			config.Name, check.Version))
+	}
+
+	return nil
+}
+
+// Columns returns the column names in a table. The list is empty if the table does not exist.
+func (s *sqlConn) Columns(ctx context.Context, table string) ([]string, error) {
+	query := "SELECT name FROM pragma_table_info('" + Escape(table) + "')"
+
+	columns, err := s.RowsStringSlice(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	return columns, nil
+}
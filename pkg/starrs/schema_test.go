@@ -0,0 +1,98 @@
+package starrs
+
+import (
+	"testing"
+
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+
+	for _, app := range starrtest.Apps() {
+		test, config := newDB(t, app)
+
+		check, err := test.CheckSchema(config)
+		if err != nil {
+			t.Fatalf("%s: %v", app, err)
+		}
+
+		// Whisparr has no known versions; the tables still match.
+		if known := app != starr.Whisparr; check.Known != known || len(check.Missing) != 0 ||
+			check.Version != starrtest.SchemaVersion(app) {
+			t.Errorf("%s: fixture schema should be compatible: %+v", app, check)
+		}
+	}
+}
+
+func TestGuardSchemaUnknown(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Sonarr, rootFolder("/tv/"), insert("Series", 1, "/tv/Show"),
+		`INSERT INTO VersionInfo (Version, Description) VALUES (999, 'from the future')`)
+
+	if check, err := test.CheckSchema(config); err != nil || check.Known || check.Version != 999 {
+		t.Fatalf("version 999 should be unknown: %+v, %v", check, err)
+	}
+
+	test.host.Answer = false
+
+	if _, err := test.UpdateRootFolder(config, "/tv/", "/shows/"); err == nil {
+		t.Error("declining an unknown schema should return an error")
+	} else if paths := test.rows(t, config, "SELECT Path FROM Series"); paths[0] != "/tv/Show" {
+		t.Errorf("the database should not change: %v", paths)
+	}
+
+	test.host.Answer = true
+
+	if _, err := test.UpdateRootFolder(config, "/tv/", "/shows/"); err != nil {
+		t.Fatal(err)
+	} else if paths := test.rows(t, config, "SELECT Path FROM Series"); paths[0] != "/shows/Show" {
+		t.Errorf("accepting an unknown schema should write: %v", paths)
+	}
+}
+
+func TestGuardSchemaMissing(t *testing.T) {
+	t.Parallel()
+
+	test, config := newDB(t, starr.Radarr, rootFolder("/movies/"), insert("Movies", 1, "/movies/Film"),
+		`ALTER TABLE Collections RENAME COLUMN RootFolderPath TO RootFolder`)
+
+	check, err := test.CheckSchema(config)
+	if err != nil || len(check.Missing) != 1 || check.Missing[0] != "Collections.RootFolderPath" {
+		t.Fatalf("the renamed column should be missing: %+v, %v", check, err)
+	}
+
+	// Missing columns cannot be overridden.
+	if _, err := test.UpdateRootFolder(config, "/movies/", "/films/"); err == nil {
+		t.Error("a missing column should return an error")
+	} else if paths := test.rows(t, config, "SELECT Path FROM Movies"); paths[0] != "/movies/Film" {
+		t.Errorf("the database should not change: %v", paths)
+	}
+}
+
+func TestSchemaRanges(t *testing.T) {
+	t.Parallel()
+
+	for _, app := range starrtest.Apps() {
+		version := starrtest.SchemaVersion(app)
+		known := SchemaRanges(app.String())
+
+		if app == starr.Whisparr {
+			if known.Min != 0 || known.Max != 0 {
+				t.Errorf("%s: no versions should be known: %+v", app, known)
+			}
+
+			continue
+		}
+
+		if known.Min <= 0 || known.Min > known.Max || version < known.Min || version > known.Max {
+			t.Errorf("%s: the fixture version %d should be inside the known range %+v", app, version, known)
+		}
+	}
+
+	if known := SchemaRanges("Nope"); known.Max != 0 {
+		t.Errorf("an unknown app should have no known versions: %+v", known)
+	}
+}
@@ -3,8 +3,11 @@ package starrs
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 
 	"github.com/Notifiarr/toolbarr/pkg/logs"
 	"github.com/jmoiron/sqlx"
@@ -32,15 +35,33 @@ type Entry struct {
 // newSQL provides a sql connection to make queries.
 // Must call Close() when finished.
 func (s *Starrs) newSQL(config *AppConfig) (*sqlConn, error) {
+	return s.openSQL(config, config.DBPath)
+}
+
+// newReadOnlySQL provides a sql connection that cannot write to the database.
+// The file is opened read only, and other databases cannot be attached.
+// Must call Close() when finished.
+func (s *Starrs) newReadOnlySQL(config *AppConfig) (*sqlConn, error) {
+	dsn := &url.URL{
+		Scheme:   "file",
+		OmitHost: true,
+		Path:     filepath.ToSlash(config.DBPath),
+		RawQuery: "mode=ro&_pragma=query_only(1)",
+	}
+
+	return s.openSQL(config, dsn.String())
+}
+
+func (s *Starrs) openSQL(config *AppConfig, dsn string) (*sqlConn, error) {
 	if stat, err := os.Stat(config.DBPath); err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Unable to open or read DB file: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Unable to open or read DB file: %v", err.Error()))
 	} else if stat.IsDir() {
-		return nil, fmt.Errorf(s.log.Translate("You picked a folder, but you need to pick a sqlite3 database FILE."))
+		return nil, errors.New(s.log.Translate("You picked a folder, but you need to pick a sqlite3 database FILE."))
 	}
 
-	conn, err := sqlx.Open("sqlite", config.DBPath)
+	conn, err := sqlx.Open("sqlite", dsn)
 	if err != nil {
-		return nil, fmt.Errorf(s.log.Translate("Unable to open or read DB file: %v", err.Error()))
+		return nil, errors.New(s.log.Translate("Unable to open or read DB file: %v", err.Error()))
 	}
 
 	return &sqlConn{
@@ -1,15 +1,24 @@
 package starrs
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Notifiarr/toolbarr/pkg/logs"
 	"github.com/Notifiarr/toolbarr/pkg/mnd"
-	wr "github.com/wailsapp/wails/v2/pkg/runtime"
 	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/prowlarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
 )
 
 const waitTime = 500 * time.Millisecond
@@ -19,9 +28,12 @@ var ErrInvalidApp = errors.New("an invalid app was provided; this may be a bug")
 // Starrs holds the running data and provides the frontend a place
 // to interact with starr instances and their databases.
 type Starrs struct {
-	ctx context.Context
-	app mnd.App
-	log *logs.Logger
+	ctx       context.Context
+	app       mnd.App
+	log       *logs.Logger
+	moves     map[string]*RootMove // App/instance name => last root folder move, so it can be seen and resumed.
+	movesMu   sync.Mutex
+	movesFile string // Optional. Moves are saved here, so they can be resumed after a restart.
 }
 
 // instance allows interacting with the instances via HTTP API using a standard interface.
@@ -93,7 +105,7 @@ func (s *Starrs) newAPIinstance(config *AppConfig) (*instance, error) {
 	if instance.APIKey == "" {
 		data, err := instance.testWithoutKey()
 		if err != nil {
-			wr.LogError(s.ctx, err.Error())
+			s.app.LogError(err.Error())
 			return nil, err
 		}
 
@@ -103,6 +115,94 @@ func (s *Starrs) newAPIinstance(config *AppConfig) (*instance, error) {
 	return instance, nil
 }
 
+// apiVersion returns the API version path prefix for a starr app.
+func apiVersion(app string) string {
+	switch starr.App(app) {
+	case starr.Lidarr:
+		return lidarr.APIver
+	case starr.Prowlarr:
+		return prowlarr.APIver
+	case starr.Readarr:
+		return readarr.APIver
+	case starr.Radarr:
+		return radarr.APIver
+	default: // Sonarr, Whisparr.
+		return sonarr.APIver
+	}
+}
+
+// getInto makes a GET request to an API path that the starr library does not provide.
+// The path should not include the api version; it is added based on the app type.
+func (i *instance) getInto(ctx context.Context, uri string, output any) error {
+	req := starr.Request{URI: path.Join(apiVersion(i.config.App), uri)}
+	if err := i.GetInto(ctx, req, output); err != nil {
+		return fmt.Errorf("api.Get(%s): %w", &req, err)
+	}
+
+	return nil
+}
+
+// postInto makes a POST request to an API path that the starr library does not provide.
+// The body is encoded to json, unless it's nil.
+func (i *instance) postInto(ctx context.Context, uri string, body, output any) error {
+	req, err := jsonRequest(i.config.App, uri, body)
+	if err != nil {
+		return err
+	}
+
+	if err := i.PostInto(ctx, req, output); err != nil {
+		return fmt.Errorf("api.Post(%s): %w", &req, err)
+	}
+
+	return nil
+}
+
+// putInto makes a PUT request to an API path that the starr library does not provide.
+func (i *instance) putInto(ctx context.Context, uri string, body, output any) error {
+	req, err := jsonRequest(i.config.App, uri, body)
+	if err != nil {
+		return err
+	}
+
+	if err := i.PutInto(ctx, req, output); err != nil {
+		return fmt.Errorf("api.Put(%s): %w", &req, err)
+	}
+
+	return nil
+}
+
+// deleteAny makes a DELETE request to an API path that the starr library does not provide.
+// The body is encoded to json, unless it's nil.
+func (i *instance) deleteAny(ctx context.Context, uri string, body any) error {
+	req, err := jsonRequest(i.config.App, uri, body)
+	if err != nil {
+		return err
+	}
+
+	if err := i.DeleteAny(ctx, req); err != nil {
+		return fmt.Errorf("api.Delete(%s): %w", &req, err)
+	}
+
+	return nil
+}
+
+// jsonRequest returns a request for an API path with a json body.
+func jsonRequest(app, uri string, body any) (starr.Request, error) {
+	req := starr.Request{URI: path.Join(apiVersion(app), uri)}
+	if body == nil {
+		return req, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return req, fmt.Errorf("json.Marshal(%s): %w", uri, err)
+	}
+
+	req.Body = bytes.NewBuffer(data)
+
+	return req, nil
+}
+
 type Selected map[int64]bool
 
 func (s Selected) Count() (count int) { //nolint:nonamedreturns
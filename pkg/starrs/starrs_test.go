@@ -0,0 +1,518 @@
+package starrs
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/toolbarr/pkg/logs"
+	"github.com/Notifiarr/toolbarr/pkg/mnd"
+	"github.com/Notifiarr/toolbarr/pkg/starrs/starrtest"
+	"golift.io/starr"
+)
+
+/* These tests drive every exported Starrs method against a fake starr app for each app type. */
+
+// ownTests are tested in their own files, with the fixture databases or more than one fake app.
+//
+//nolint:gochecknoglobals
+var ownTests = map[string]bool{
+	"CheckDB":                true,
+	"CheckDBInUse":           true,
+	"CheckSchema":            true,
+	"DBTables":               true,
+	"RunQuery":               true,
+	"RunReport":              true,
+	"ExportQuery":            true,
+	"RepairDB":               true,
+	"MigratorInfo":           true,
+	"DeleteRootFolder":       true,
+	"UpdateRootFolder":       true,
+	"UpdateRecycleBin":       true,
+	"UpdateInvalidItems":     true,
+	"PreviewMerge":           true,
+	"MergeInstances":         true,
+	"Library":                true,
+	"EditLibrary":            true,
+	"MoveRootFolder":         true,
+	"RootMoveState":          true,
+	"RootFolderDetails":      true,
+	"RootFoldersAll":         true,
+	"AddRootFolder":          true,
+	"RemoveRootFolder":       true,
+	"ImportUnmapped":         true,
+	"DeleteUnmapped":         true,
+	"PreviewExclusionImport": true,
+	"BulkAddExclusions":      true,
+	"AnalyzeExclusions":      true,
+	"DeleteExclusions":       true,
+	"PreviewImportList":      true,
+	"SyncImportLists":        true,
+	"SyncImportListsAll":     true,
+}
+
+// kind is a resource with the same list, add, update, test, delete, export and import methods for each app.
+type kind struct {
+	name     string // Used in the typed method names: Add<App><name>.
+	list     string
+	del      string
+	export   string
+	imp      string
+	test     bool // Has Test<App><name> methods.
+	apps     []starr.App
+	resource func(starr.App) string // Resource name in the fake server.
+}
+
+//nolint:gochecknoglobals
+var (
+	allApps = []starr.App{starr.Lidarr, starr.Prowlarr, starr.Radarr, starr.Readarr, starr.Sonarr, starr.Whisparr}
+	arrApps = []starr.App{starr.Lidarr, starr.Radarr, starr.Readarr, starr.Sonarr, starr.Whisparr}
+	kinds   = []*kind{{
+		name: "Indexer", list: "Indexers", del: "DeleteIndexer", export: "ExportIndexer", imp: "ImportIndexer",
+		test: true, apps: allApps, resource: func(starr.App) string { return "indexer" },
+	}, {
+		name: "DownloadClient", list: "Downloaders", del: "DeleteDownloader",
+		export: "ExportDownloadClients", imp: "ImportDownloadClients",
+		test: true, apps: allApps, resource: func(starr.App) string { return "downloadclient" },
+	}, {
+		name: "ImportList", list: "ImportLists", del: "DeleteImportList",
+		export: "ExportImportLists", imp: "ImportImportLists",
+		test: true, apps: arrApps, resource: func(starr.App) string { return "importlist" },
+	}, {
+		name: "Exclusion", list: "Exclusions", del: "DeleteExclusion",
+		export: "ExportExclusions", imp: "ImportExclusions",
+		apps: arrApps, resource: exclusionResource,
+	}, {
+		name: "QualityProfile", list: "QualityProfiles", del: "DeleteQualityProfile",
+		export: "ExportQualityProfiles", imp: "ImportQualityProfiles",
+		apps: arrApps, resource: func(starr.App) string { return "qualityprofile" },
+	}}
+)
+
+func exclusionResource(app starr.App) string {
+	if app == starr.Radarr {
+		return "exclusions"
+	}
+
+	return "importlistexclusion"
+}
+
+func (k *kind) has(app starr.App) bool {
+	for _, a := range k.apps {
+		if a == app {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tester calls Starrs methods by name, like the frontend does, and remembers which were called.
+type tester struct {
+	*Starrs
+	host   *mnd.Headless
+	called sync.Map
+}
+
+func newTester(t *testing.T) *tester {
+	t.Helper()
+
+	dir := t.TempDir()
+	test := &tester{
+		Starrs: &Starrs{},
+		host: &mnd.Headless{
+			Answer:   true,
+			SavePath: filepath.Join(dir, "export.json"),
+			OpenPath: filepath.Join(dir, "export.json"),
+		},
+	}
+	Startup(context.Background(), test.Starrs, logs.New(), test.host)
+
+	return test
+}
+
+func newConfig(server *starrtest.Server) *AppConfig {
+	return &AppConfig{
+		App:     server.App.String(),
+		Name:    "test" + server.App.String(),
+		URL:     server.URL(),
+		Key:     starrtest.APIKey,
+		Timeout: 5 * time.Second,
+	}
+}
+
+// call runs a Starrs method with the provided arguments and returns its output and error.
+func (s *tester) call(t *testing.T, name string, args ...any) (any, error) {
+	t.Helper()
+
+	method := reflect.ValueOf(s.Starrs).MethodByName(name)
+	if !method.IsValid() {
+		t.Fatalf("Starrs has no method %s", name)
+	}
+
+	input := make([]reflect.Value, len(args))
+	for idx, arg := range args {
+		input[idx] = reflect.ValueOf(arg)
+	}
+
+	s.called.Store(name, true)
+
+	return output(method.Call(input))
+}
+
+// callTyped runs an app-specific method, like AddRadarrIndexer. The item argument
+// is created with the provided id and name, and the force argument is false.
+func (s *tester) callTyped(t *testing.T, name string, config *AppConfig, itemID int64, itemName string) (any, error) {
+	t.Helper()
+
+	method := reflect.ValueOf(s.Starrs).MethodByName(name)
+	if !method.IsValid() {
+		t.Fatalf("Starrs has no method %s", name)
+	}
+
+	mType := method.Type()
+	input := make([]reflect.Value, mType.NumIn())
+
+	for idx := range input {
+		switch arg := mType.In(idx); {
+		case arg == reflect.TypeOf(config):
+			input[idx] = reflect.ValueOf(config)
+		case arg.Kind() == reflect.Bool:
+			input[idx] = reflect.ValueOf(false)
+		case arg.Kind() == reflect.Pointer && arg.Elem().Kind() == reflect.Struct:
+			input[idx] = newItem(arg.Elem(), itemID, itemName)
+		default:
+			t.Fatalf("%s has an unexpected argument type: %v", name, arg)
+		}
+	}
+
+	s.called.Store(name, true)
+
+	return output(method.Call(input))
+}
+
+// newItem creates an input item, like an IndexerInput or an Exclusion, with an id and a name.
+func newItem(elem reflect.Type, itemID int64, itemName string) reflect.Value {
+	item := reflect.New(elem)
+
+	if field := item.Elem().FieldByName("ID"); field.IsValid() {
+		field.SetInt(itemID)
+	}
+
+	for _, name := range []string{"Name", "Title", "ArtistName", "AuthorName"} {
+		if field := item.Elem().FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+			field.SetString(itemName)
+			break
+		}
+	}
+
+	return item
+}
+
+func output(values []reflect.Value) (any, error) {
+	var err error
+	if last := values[len(values)-1]; last.Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
+		err, _ = last.Interface().(error)
+		values = values[:len(values)-1]
+	}
+
+	if len(values) == 0 {
+		return nil, err
+	}
+
+	return values[0].Interface(), err
+}
+
+// hasValue returns true if any value in the item is equal to the provided value.
+func hasValue(item starrtest.Item, value any) bool {
+	for _, v := range item {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStarrs(t *testing.T) {
+	t.Parallel()
+
+	test := newTester(t)
+
+	t.Run("apps", func(t *testing.T) {
+		for _, app := range allApps {
+			t.Run(app.String(), func(t *testing.T) {
+				t.Parallel()
+				test.testApp(t, app)
+			})
+		}
+	})
+
+	// Exports and imports share the last picked folder, so they do not run in parallel.
+	t.Run("export", func(t *testing.T) {
+		for _, app := range allApps {
+			test.testExportImport(t, app)
+		}
+	})
+
+	t.Run("health", test.testHealthAll)
+	t.Run("discover", test.testDiscover)
+	t.Run("configxml", test.testReadConfigXML)
+
+	// Every exported method must be tested here, or with a fixture database.
+	rType := reflect.TypeOf(test.Starrs)
+	for idx := range rType.NumMethod() {
+		name := rType.Method(idx).Name
+		if _, ok := test.called.Load(name); !ok && !ownTests[name] {
+			t.Errorf("Exported method not tested: %s", name)
+		}
+	}
+}
+
+func (s *tester) testApp(t *testing.T, app starr.App) {
+	t.Helper()
+
+	server := starrtest.New(app)
+	defer server.Close()
+
+	config := newConfig(server)
+
+	for _, kind := range kinds {
+		if kind.has(app) {
+			s.testKind(t, server, config, kind)
+		}
+	}
+
+	s.testMisc(t, server, config)
+	s.testInstance(t, server, config)
+	s.testShutdown(t, server, config)
+}
+
+// testShutdown restarts and stops the app. The fake app stops answering after a shutdown, so this runs last.
+func (s *tester) testShutdown(t *testing.T, server *starrtest.Server, config *AppConfig) {
+	t.Helper()
+
+	app := starr.App(config.App)
+	apiPath := "POST /api/" + starrtest.APIVersion(app) + "/system/"
+
+	if _, err := s.call(t, "RestartInstance", config); err != nil || !server.Called(apiPath+"restart") {
+		t.Errorf("%s: restarting: %v", app, err)
+	}
+
+	if _, err := s.call(t, "ShutdownInstance", config); err != nil || !server.Called(apiPath+"shutdown") {
+		t.Errorf("%s: stopping: %v", app, err)
+	}
+
+	if _, err := s.TestInstance(config); err == nil {
+		t.Errorf("%s: a stopped app should not answer", app)
+	}
+}
+
+// testKind adds, lists, updates, tests and deletes an item.
+func (s *tester) testKind(t *testing.T, server *starrtest.Server, config *AppConfig, kind *kind) {
+	t.Helper()
+
+	app := starr.App(config.App)
+	resource := kind.resource(app)
+
+	if _, err := s.callTyped(t, "Add"+app.String()+kind.name, config, 0, "one"); err != nil {
+		t.Fatalf("%s: adding %s: %v", app, kind.name, err)
+	}
+
+	items := server.Items(resource)
+	if len(items) != 1 || !hasValue(items[0], "one") {
+		t.Fatalf("%s: expected one %s named one, got: %v", app, kind.name, items)
+	}
+
+	itemID := items[0].ID()
+
+	if _, err := s.call(t, kind.list, config); err != nil {
+		t.Errorf("%s: listing %s: %v", app, kind.name, err)
+	}
+
+	if _, err := s.callTyped(t, "Update"+app.String()+kind.name, config, itemID, "two"); err != nil {
+		t.Errorf("%s: updating %s: %v", app, kind.name, err)
+	} else if items = server.Items(resource); len(items) != 1 || !hasValue(items[0], "two") {
+		t.Errorf("%s: expected %s to be renamed two, got: %v", app, kind.name, items)
+	}
+
+	if kind.test {
+		if _, err := s.callTyped(t, "Test"+app.String()+kind.name, config, itemID, "two"); err != nil {
+			t.Errorf("%s: testing %s: %v", app, kind.name, err)
+		}
+
+		_, err := s.callTyped(t, "Test"+app.String()+kind.name, config, itemID, starrtest.FailName)
+		if err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Errorf("%s: testing %s should fail with the validation message, got: %v", app, kind.name, err)
+		}
+	}
+
+	if _, err := s.call(t, kind.del, config, itemID); err != nil {
+		t.Errorf("%s: deleting %s: %v", app, kind.name, err)
+	} else if items = server.Items(resource); len(items) != 0 {
+		t.Errorf("%s: expected %s to be deleted, got: %v", app, kind.name, items)
+	}
+}
+
+// testMisc tests the methods that are not the same for every app.
+func (s *tester) testMisc(t *testing.T, server *starrtest.Server, config *AppConfig) {
+	t.Helper()
+
+	app := starr.App(config.App)
+	server.Seed("tag", starrtest.Item{"id": 1, "label": "one"})
+	server.Seed("rootfolder", starrtest.Item{"path": "/movies/"})
+	server.Seed("metadataprofile", starrtest.Item{"name": "Standard"})
+	server.Seed("blocklist", starrtest.Item{"sourceTitle": "a"}, starrtest.Item{"sourceTitle": "b"})
+
+	if tags, err := s.Tags(config); err != nil || tags[1] != "one" {
+		t.Errorf("%s: expected tag one, got: %v, %v", app, tags, err)
+	}
+
+	s.called.Store("Tags", true)
+
+	_, err := s.call(t, "RootFolders", config)
+	if wantErr := app == starr.Prowlarr; (err != nil) != wantErr {
+		t.Errorf("%s: root folders: wanted error: %v, got: %v", app, wantErr, err)
+	}
+
+	_, err = s.call(t, "MetadataProfiles", config)
+	if wantErr := app != starr.Lidarr && app != starr.Readarr; (err != nil) != wantErr {
+		t.Errorf("%s: metadata profiles: wanted error: %v, got: %v", app, wantErr, err)
+	}
+
+	_, err = s.call(t, "BlockList", config, 1, 1, "date", "descending")
+	if wantErr := app == starr.Prowlarr; (err != nil) != wantErr {
+		t.Errorf("%s: block list: wanted error: %v, got: %v", app, wantErr, err)
+	}
+
+	listID := server.Items("blocklist")[0].ID()
+	if _, err = s.call(t, "DeleteBlockList", config, listID); app != starr.Prowlarr && err != nil {
+		t.Errorf("%s: deleting block list: %v", app, err)
+	} else if app != starr.Prowlarr && len(server.Items("blocklist")) != 1 {
+		t.Errorf("%s: expected one block list item after delete, got: %v", app, server.Items("blocklist"))
+	}
+
+	// These are not written yet.
+	for _, name := range []string{"AppProfiles", "CustomFilters"} {
+		if _, err := s.call(t, name, config); err == nil {
+			t.Errorf("%s: %s should return an error", app, name)
+		}
+	}
+}
+
+// testInstance tests connecting with and without an api key, and a bad key.
+func (s *tester) testInstance(t *testing.T, server *starrtest.Server, config *AppConfig) {
+	t.Helper()
+
+	app := starr.App(config.App)
+
+	if msg, err := s.call(t, "TestInstance", config); err != nil || !strings.Contains(msg.(string), starrtest.Version) {
+		t.Errorf("%s: testing instance: %v, %v", app, msg, err)
+	}
+
+	noKey := *config
+	noKey.Key = ""
+
+	if _, err := s.TestInstance(&noKey); err != nil || !server.Called("GET /initialize.js") {
+		t.Errorf("%s: testing instance without a key should use initialize.js: %v", app, err)
+	}
+
+	badKey := *config
+	badKey.Key = "bad"
+
+	if _, err := s.Indexers(&badKey); err == nil {
+		t.Errorf("%s: a bad api key should return an error", app)
+	}
+
+	health, _ := s.call(t, "Health", config)
+	if report := health.(*InstanceHealth); report.Version != starrtest.Version || len(report.Errors) != 0 {
+		t.Errorf("%s: unexpected health report: %+v", app, report)
+	}
+}
+
+// testExportImport saves one item of each kind to a file, and reads it back.
+func (s *tester) testExportImport(t *testing.T, app starr.App) {
+	t.Helper()
+
+	server := starrtest.New(app)
+	defer server.Close()
+
+	config := newConfig(server)
+
+	for _, kind := range kinds {
+		if !kind.has(app) {
+			continue
+		}
+
+		server.Seed(kind.resource(app), starrtest.Item{"id": 10, "name": "keep"}, starrtest.Item{"id": 11, "name": "skip"})
+
+		if _, err := s.call(t, kind.export, config, Selected{10: true}); err != nil {
+			t.Errorf("%s: exporting %s: %v", app, kind.name, err)
+			continue
+		}
+
+		reply, err := s.call(t, kind.imp, config)
+		if err != nil {
+			t.Errorf("%s: importing %s: %v", app, kind.name, err)
+			continue
+		}
+
+		if data := reflect.ValueOf(reply.(*DataReply).Data); data.Len() != 1 {
+			t.Errorf("%s: expected 1 exported %s, got %d", app, kind.name, data.Len())
+		}
+	}
+}
+
+func (s *tester) testHealthAll(t *testing.T) {
+	instances := make(Instances)
+
+	for _, app := range allApps {
+		server := starrtest.New(app)
+		defer server.Close()
+
+		instances[app.String()] = []AppConfig{*newConfig(server)}
+	}
+
+	matrix, _ := s.call(t, "HealthAll", instances)
+	if report := matrix.(*HealthMatrix); report.Severity != SeverityOK || len(report.Instances) != len(allApps) {
+		t.Errorf("unexpected health matrix: %+v", report)
+	}
+}
+
+func (s *tester) testDiscover(t *testing.T) {
+	server := starrtest.New(starr.Radarr)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL())
+	host, port, _ := net.SplitHostPort(u.Host)
+	portNum, _ := strconv.Atoi(port)
+
+	found, err := s.call(t, "Discover", &DiscoverInput{Hosts: []string{host}, Ports: []int{portNum}, Timeout: time.Second})
+	if list := found.([]*Discovered); err != nil || len(list) != 1 || list[0].Config.App != starr.Radarr.String() {
+		t.Errorf("expected to discover radarr: %v", err)
+	} else if list[0].Config.Timeout != 0 {
+		t.Errorf("the probe timeout should not be saved with the instance: %v", list[0].Config.Timeout)
+	}
+}
+
+func (s *tester) testReadConfigXML(t *testing.T) {
+	dir := t.TempDir()
+	xml := "<Config><Port>7878</Port><ApiKey>" + starrtest.APIKey + "</ApiKey><UrlBase>/radarr</UrlBase></Config>"
+
+	if err := os.WriteFile(filepath.Join(dir, configXML), []byte(xml), mnd.Mode0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := s.call(t, "ReadConfigXML", &AppConfig{App: starr.Radarr.String()}, dir)
+	if err != nil || config.(*AppConfig).Key != starrtest.APIKey {
+		t.Errorf("reading config.xml: %v, %v", config, err)
+	}
+}
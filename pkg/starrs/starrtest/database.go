@@ -0,0 +1,147 @@
+package starrtest
+
+import (
+	"database/sql"
+	"fmt"
+
+	"golift.io/starr"
+	_ "modernc.org/sqlite" // database driver for sqlite3.
+)
+
+/* Fixture databases. The tables match the starr app schemas for the columns toolbarr uses. */
+
+// schemaVersions are the migration versions written to the VersionInfo table.
+// These are inside the ranges the starrs package knows how to write to. Whisparr has no known range.
+//
+//nolint:gochecknoglobals,gomnd
+var schemaVersions = map[starr.App]int64{
+	starr.Lidarr:   77,
+	starr.Radarr:   230,
+	starr.Readarr:  40,
+	starr.Sonarr:   205,
+	starr.Whisparr: 205,
+}
+
+// SchemaVersion returns the migration version in the VersionInfo table for an app's fixture database.
+func SchemaVersion(app starr.App) int64 {
+	return schemaVersions[app]
+}
+
+// Shared tables exist in every app database.
+//
+//nolint:gochecknoglobals
+var sharedTables = []string{
+	`CREATE TABLE "VersionInfo" ("Version" INTEGER NOT NULL, "AppliedOn" DATETIME, "Description" TEXT)`,
+	`CREATE UNIQUE INDEX "UC_Version" ON "VersionInfo" ("Version" ASC)`,
+	`CREATE TABLE "Config" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Key" TEXT NOT NULL, "Value" TEXT NOT NULL)`,
+	`CREATE UNIQUE INDEX "IX_Config_Key" ON "Config" ("Key" ASC)`,
+	`CREATE TABLE "RootFolders" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Path" TEXT NOT NULL)`,
+	`CREATE UNIQUE INDEX "IX_RootFolders_Path" ON "RootFolders" ("Path" ASC)`,
+	`CREATE TABLE "Tags" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Label" TEXT NOT NULL)`,
+	`CREATE UNIQUE INDEX "IX_Tags_Label" ON "Tags" ("Label" ASC)`,
+	`CREATE TABLE "ImportLists" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Name" TEXT NOT NULL,
+		"Implementation" TEXT NOT NULL, "ConfigContract" TEXT, "Settings" TEXT, "EnableAutomaticAdd" INTEGER,
+		"RootFolderPath" TEXT NOT NULL, "QualityProfileId" INTEGER, "Tags" TEXT)`,
+	`CREATE UNIQUE INDEX "IX_ImportLists_Name" ON "ImportLists" ("Name" ASC)`,
+}
+
+// appTables are the tables for each app, beyond the shared tables.
+//
+//nolint:gochecknoglobals
+var appTables = map[starr.App][]string{
+	starr.Lidarr: {
+		`CREATE TABLE "Artists" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "ArtistMetadataId" INTEGER NOT NULL,
+			"CleanName" TEXT, "Path" TEXT NOT NULL, "Monitored" INTEGER NOT NULL, "QualityProfileId" INTEGER,
+			"MetadataProfileId" INTEGER, "Added" DATETIME, "Tags" TEXT)`,
+		`CREATE UNIQUE INDEX "IX_Artists_ArtistMetadataId" ON "Artists" ("ArtistMetadataId" ASC)`,
+		`CREATE TABLE "TrackFiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "AlbumId" INTEGER NOT NULL,
+			"Quality" TEXT NOT NULL, "Size" INTEGER NOT NULL, "SceneName" TEXT, "DateAdded" DATETIME,
+			"ReleaseGroup" TEXT, "MediaInfo" TEXT, "Modified" DATETIME, "OriginalFilePath" TEXT, "Path" TEXT NOT NULL)`,
+		`CREATE UNIQUE INDEX "IX_TrackFiles_Path" ON "TrackFiles" ("Path" ASC)`,
+		`CREATE TABLE "Albums" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "ArtistMetadataId" INTEGER NOT NULL,
+			"ForeignAlbumId" TEXT NOT NULL, "Title" TEXT NOT NULL, "CleanTitle" TEXT, "Monitored" INTEGER NOT NULL)`,
+		`CREATE TABLE "ArtistMetadata" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "ForeignArtistId" TEXT NOT NULL,
+			"Name" TEXT NOT NULL)`,
+		`CREATE TABLE "QualityProfiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Name" TEXT NOT NULL,
+			"Cutoff" INTEGER NOT NULL, "Items" TEXT NOT NULL)`,
+	},
+	starr.Radarr: {
+		`CREATE TABLE "Movies" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Path" TEXT NOT NULL,
+			"Monitored" INTEGER NOT NULL, "QualityProfileId" INTEGER NOT NULL, "Added" DATETIME, "Tags" TEXT,
+			"MovieFileId" INTEGER NOT NULL, "MinimumAvailability" INTEGER NOT NULL, "MovieMetadataId" INTEGER NOT NULL)`,
+		`CREATE UNIQUE INDEX "IX_Movies_MovieMetadataId" ON "Movies" ("MovieMetadataId" ASC)`,
+		`CREATE TABLE "MovieFiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "MovieId" INTEGER NOT NULL,
+			"Quality" TEXT NOT NULL, "Size" INTEGER NOT NULL, "DateAdded" DATETIME, "SceneName" TEXT,
+			"MediaInfo" TEXT, "ReleaseGroup" TEXT, "RelativePath" TEXT, "OriginalFilePath" TEXT)`,
+		`CREATE TABLE "Collections" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "TmdbId" INTEGER NOT NULL,
+			"QualityProfileId" INTEGER NOT NULL, "RootFolderPath" TEXT NOT NULL, "MinimumAvailability" INTEGER,
+			"SearchOnAdd" INTEGER, "Title" TEXT NOT NULL, "SortTitle" TEXT, "CleanTitle" TEXT, "Overview" TEXT,
+			"Monitored" INTEGER NOT NULL, "Added" DATETIME, "LastInfoSync" DATETIME, "Tags" TEXT)`,
+		`CREATE UNIQUE INDEX "IX_Collections_TmdbId" ON "Collections" ("TmdbId" ASC)`,
+		`CREATE TABLE "MovieMetadata" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "TmdbId" INTEGER NOT NULL,
+			"Title" TEXT NOT NULL)`,
+		`CREATE TABLE "Profiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Name" TEXT NOT NULL,
+			"Cutoff" INTEGER NOT NULL, "Items" TEXT NOT NULL)`,
+	},
+	starr.Readarr: {
+		`CREATE TABLE "Authors" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "AuthorMetadataId" INTEGER NOT NULL,
+			"CleanName" TEXT, "Path" TEXT NOT NULL, "Monitored" INTEGER NOT NULL, "QualityProfileId" INTEGER,
+			"MetadataProfileId" INTEGER, "Added" DATETIME, "Tags" TEXT)`,
+		`CREATE UNIQUE INDEX "IX_Authors_AuthorMetadataId" ON "Authors" ("AuthorMetadataId" ASC)`,
+		`CREATE TABLE "BookFiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "EditionId" INTEGER NOT NULL,
+			"CalibreId" INTEGER NOT NULL, "Quality" TEXT NOT NULL, "Size" INTEGER NOT NULL, "SceneName" TEXT,
+			"DateAdded" DATETIME, "ReleaseGroup" TEXT, "MediaInfo" TEXT, "Modified" DATETIME,
+			"OriginalFilePath" TEXT, "Path" TEXT NOT NULL)`,
+		`CREATE UNIQUE INDEX "IX_BookFiles_Path" ON "BookFiles" ("Path" ASC)`,
+		`CREATE TABLE "AuthorMetadata" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "ForeignAuthorId" TEXT NOT NULL,
+			"Name" TEXT NOT NULL)`,
+		`CREATE TABLE "QualityProfiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Name" TEXT NOT NULL,
+			"Cutoff" INTEGER NOT NULL, "Items" TEXT NOT NULL)`,
+	},
+	starr.Sonarr:   seriesTables(),
+	starr.Whisparr: seriesTables(),
+}
+
+func seriesTables() []string {
+	return []string{
+		`CREATE TABLE "Series" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "TvdbId" INTEGER NOT NULL,
+			"Title" TEXT NOT NULL, "CleanTitle" TEXT, "Path" TEXT NOT NULL, "Monitored" INTEGER NOT NULL,
+			"QualityProfileId" INTEGER, "SeasonFolder" INTEGER, "Added" DATETIME, "LastInfoSync" DATETIME, "Tags" TEXT)`,
+		`CREATE UNIQUE INDEX "IX_Series_TvdbId" ON "Series" ("TvdbId" ASC)`,
+		`CREATE UNIQUE INDEX "IX_Series_Path" ON "Series" ("Path" ASC)`,
+		`CREATE TABLE "EpisodeFiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "SeriesId" INTEGER NOT NULL,
+			"SeasonNumber" INTEGER NOT NULL, "RelativePath" TEXT, "Size" INTEGER NOT NULL, "DateAdded" DATETIME,
+			"SceneName" TEXT, "Quality" TEXT NOT NULL, "MediaInfo" TEXT, "OriginalFilePath" TEXT)`,
+		`CREATE TABLE "QualityProfiles" ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Name" TEXT NOT NULL,
+			"Cutoff" INTEGER NOT NULL, "Items" TEXT NOT NULL)`,
+	}
+}
+
+// NewDB creates a sqlite3 database file for an app, then runs the provided queries to fill it.
+func NewDB(path string, app starr.App, queries ...string) error {
+	tables, ok := appTables[app]
+	if !ok {
+		return fmt.Errorf("%w: no database schema for %s", starr.ErrRequestError, app)
+	}
+
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer conn.Close()
+
+	version := fmt.Sprintf(`INSERT INTO "VersionInfo" VALUES (%d, '2024-01-01T00:00:00', 'fixture')`, SchemaVersion(app))
+
+	for _, query := range append(append(append(sharedTables, tables...), version), queries...) {
+		if _, err := conn.Exec(query); err != nil {
+			return fmt.Errorf("%s: %w", query, err)
+		}
+	}
+
+	return nil
+}
+
+// Apps returns the apps NewDB can create databases for.
+func Apps() []starr.App {
+	return []starr.App{starr.Lidarr, starr.Radarr, starr.Readarr, starr.Sonarr, starr.Whisparr}
+}
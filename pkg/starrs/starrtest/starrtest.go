@@ -0,0 +1,549 @@
+// Package starrtest provides an in-process fake starr app for tests.
+// It speaks enough of the Sonarr, Radarr, Lidarr, Readarr, Prowlarr and Whisparr APIs
+// for the starrs package: system status, initialize.js, and list/add/update/delete/test
+// for every resource, plus the library editors, lookups and commands, with in-memory state.
+// system/shutdown stops the fake app from answering.
+package starrtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golift.io/starr"
+)
+
+// APIKey is the key the fake server accepts.
+const APIKey = "0123456789abcdef0123456789abcdef"
+
+// Version is the app version reported by the fake server.
+const Version = "4.0.0.1"
+
+// FailName makes a test request fail when it is the name of the item being tested.
+const FailName = "fail"
+
+// Item is a resource stored in the fake server. Every item has a numeric "id".
+type Item map[string]any
+
+// Server is a fake starr app.
+type Server struct {
+	*httptest.Server
+	App starr.App
+	// Requests has one entry per request: "METHOD /path".
+	Requests []string
+	mu       sync.Mutex
+	items    map[string]map[int64]Item // resource => id => item.
+	nextID   int64
+	stopped  bool                         // Set by system/shutdown. Every request fails after that.
+	commands map[string]map[string][]Item // Command name => resource => items added when it runs.
+	messages map[string]string            // Command name => message on the finished command.
+}
+
+// New starts a fake starr app. Call Close when done.
+func New(app starr.App) *Server {
+	server := &Server{App: app, items: make(map[string]map[int64]Item)}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+
+	return server
+}
+
+// NewTLS starts a fake starr app that only answers https, with a self-signed certificate. Call Close when done.
+func NewTLS(app starr.App) *Server {
+	server := &Server{App: app, items: make(map[string]map[int64]Item)}
+	server.Server = httptest.NewTLSServer(http.HandlerFunc(server.handle))
+
+	return server
+}
+
+// APIVersion returns the API version path for the app: v1 or v3.
+func APIVersion(app starr.App) string {
+	switch app {
+	case starr.Lidarr, starr.Prowlarr, starr.Readarr:
+		return "v1"
+	default:
+		return "v3"
+	}
+}
+
+// Seed adds items to a resource, like "indexer" or "tag". Items without an id get one.
+func (s *Server) Seed(resource string, items ...Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		s.store(strings.ToLower(resource), item)
+	}
+}
+
+// SeedOnCommand adds items to a resource when a command runs, like ImportListSync adding movies.
+func (s *Server) SeedOnCommand(command, resource string, items ...Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.commands == nil {
+		s.commands = make(map[string]map[string][]Item)
+	}
+
+	if s.commands[command] == nil {
+		s.commands[command] = make(map[string][]Item)
+	}
+
+	s.commands[command][strings.ToLower(resource)] = append(s.commands[command][strings.ToLower(resource)], items...)
+}
+
+// CommandMessage sets the message on a command when it finishes, like the counts on an ImportListSync.
+func (s *Server) CommandMessage(command, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.messages == nil {
+		s.messages = make(map[string]string)
+	}
+
+	s.messages[command] = message
+}
+
+// Items returns the items in a resource, sorted by id.
+func (s *Server) Items(resource string) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list(strings.ToLower(resource))
+}
+
+// Called returns true if a request was made. Provide "METHOD /path".
+func (s *Server) Called(request string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, req := range s.Requests {
+		if strings.EqualFold(req, request) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) store(resource string, item Item) Item {
+	if s.items[resource] == nil {
+		s.items[resource] = make(map[int64]Item)
+	}
+
+	id := item.ID()
+	if id == 0 {
+		s.nextID++
+		id = s.nextID
+		item["id"] = id
+	} else if id > s.nextID {
+		s.nextID = id
+	}
+
+	s.items[resource][id] = item
+
+	return item
+}
+
+func (s *Server) list(resource string) []Item {
+	list := make([]Item, 0, len(s.items[resource]))
+	for _, item := range s.items[resource] {
+		list = append(list, item)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID() < list[j].ID() })
+
+	return list
+}
+
+// ID returns the item's id.
+func (i Item) ID() int64 {
+	switch id := i["id"].(type) {
+	case int:
+		return int64(id)
+	case int64:
+		return id
+	case float64:
+		return int64(id)
+	default:
+		return 0
+	}
+}
+
+func (s *Server) handle(resp http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Requests = append(s.Requests, req.Method+" "+req.URL.Path)
+
+	if s.stopped {
+		writeJSON(resp, http.StatusServiceUnavailable, map[string]string{"message": "shut down"})
+		return
+	}
+
+	if req.URL.Path == "/initialize.js" {
+		s.initializeJS(resp)
+		return
+	}
+
+	if req.Header.Get("X-Api-Key") != APIKey {
+		writeJSON(resp, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return
+	}
+
+	// The starr library must use the right API version for each app.
+	prefix := "/api/" + APIVersion(s.App) + "/"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		writeJSON(resp, http.StatusNotFound, map[string]string{"message": "wrong api version: " + req.URL.Path})
+		return
+	}
+
+	parts := strings.Split(strings.ToLower(strings.Trim(strings.TrimPrefix(req.URL.Path, prefix), "/")), "/")
+	s.route(resp, req, parts)
+}
+
+func (s *Server) route(resp http.ResponseWriter, req *http.Request, parts []string) { //nolint:cyclop
+	resource := parts[0]
+
+	switch {
+	case resource == "system" && len(parts) == 2 && parts[1] == "status":
+		writeJSON(resp, http.StatusOK, s.status())
+	case resource == "system" && len(parts) == 2 && req.Method == http.MethodPost:
+		s.stopped = parts[1] == "shutdown"
+		writeJSON(resp, http.StatusOK, map[string]any{})
+	case resource == "health", resource == "diskspace", resource == "update":
+		writeJSON(resp, http.StatusOK, s.list(resource))
+	case resource == "blocklist" && len(parts) == 1 && req.Method == http.MethodGet:
+		s.page(resp, req, resource)
+	case len(parts) == 2 && parts[1] == "test" && req.Method == http.MethodPost:
+		s.test(resp, req)
+	case len(parts) == 2 && parts[1] == "bulk" && req.Method == http.MethodPost:
+		s.bulk(resp, req, resource)
+	case len(parts) == 2 && parts[1] == "bulk" && req.Method == http.MethodDelete:
+		s.bulkDelete(resp, req, resource)
+	case len(parts) == 2 && parts[1] == "editor" && req.Method == http.MethodPut:
+		s.editor(resp, req, resource)
+	case len(parts) == 2 && parts[1] == "lookup" && req.Method == http.MethodGet:
+		s.lookup(resp, req, resource)
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		writeJSON(resp, http.StatusOK, s.list(resource))
+	case resource == "command" && len(parts) == 1 && req.Method == http.MethodPost:
+		s.command(resp, req)
+	case len(parts) == 1 && req.Method == http.MethodPost:
+		s.add(resp, req, resource)
+	case len(parts) == 2:
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil && req.Method == http.MethodGet {
+			// Sub-resources, like importlist/movie, list the items seeded with that name.
+			writeJSON(resp, http.StatusOK, s.list(resource+"/"+parts[1]))
+			return
+		} else if err != nil {
+			writeJSON(resp, http.StatusNotFound, map[string]string{"message": "not found"})
+			return
+		}
+
+		s.item(resp, req, resource, id)
+	default:
+		writeJSON(resp, http.StatusNotFound, map[string]string{"message": "not found"})
+	}
+}
+
+func (s *Server) item(resp http.ResponseWriter, req *http.Request, resource string, id int64) {
+	item, ok := s.items[resource][id]
+	if !ok {
+		writeJSON(resp, http.StatusNotFound, map[string]string{"message": "not found"})
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(resp, http.StatusOK, item)
+	case http.MethodDelete:
+		delete(s.items[resource], id)
+		writeJSON(resp, http.StatusOK, map[string]any{})
+	case http.MethodPut:
+		update, ok := readItem(resp, req)
+		if ok {
+			update["id"] = id
+			writeJSON(resp, http.StatusAccepted, s.store(resource, update))
+		}
+	default:
+		writeJSON(resp, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+	}
+}
+
+func (s *Server) add(resp http.ResponseWriter, req *http.Request, resource string) {
+	item, ok := readItem(resp, req)
+	if ok {
+		delete(item, "id")
+		writeJSON(resp, http.StatusCreated, s.store(resource, item))
+	}
+}
+
+// bulk adds a list of items.
+func (s *Server) bulk(resp http.ResponseWriter, req *http.Request, resource string) {
+	items := []Item{}
+	if err := json.NewDecoder(req.Body).Decode(&items); err != nil {
+		writeJSON(resp, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	for _, item := range items {
+		delete(item, "id")
+		s.store(resource, item)
+	}
+
+	writeJSON(resp, http.StatusCreated, items)
+}
+
+// command runs a command right away, and returns it completed.
+// Items seeded for the command are added, once. Items without lists get the command's definitionId, like
+// the items a single import list sync finds.
+func (s *Server) command(resp http.ResponseWriter, req *http.Request) {
+	command, ok := readItem(resp, req)
+	if !ok {
+		return
+	}
+
+	name, _ := command["name"].(string)
+	for resource, items := range s.commands[name] {
+		for _, item := range items {
+			item = maps.Clone(item)
+			if _, ok := item["lists"]; !ok && command["definitionId"] != nil {
+				item["lists"] = []any{command["definitionId"]}
+			}
+
+			s.store(resource, item)
+		}
+	}
+
+	delete(s.commands, name)
+	delete(command, "id")
+	command["status"] = "completed"
+
+	if message, ok := s.messages[name]; ok {
+		command["message"] = message
+	}
+
+	writeJSON(resp, http.StatusCreated, s.store("command", command))
+}
+
+// bulkDelete removes the items listed in the ids field.
+func (s *Server) bulkDelete(resp http.ResponseWriter, req *http.Request, resource string) {
+	input, ok := readItem(resp, req)
+	if !ok {
+		return
+	}
+
+	for _, id := range jsonList(input["ids"]) {
+		delete(s.items[resource], Item{"id": id}.ID())
+	}
+
+	writeJSON(resp, http.StatusOK, map[string]any{})
+}
+
+// lookup returns the items seeded in "<resource>/lookup" that contain the search term.
+// Terms like tmdb:123 match items with a field equal to the id after the colon.
+func (s *Server) lookup(resp http.ResponseWriter, req *http.Request, resource string) {
+	term := strings.ToLower(req.URL.Query().Get("term"))
+	_, id, byID := strings.Cut(term, ":")
+	found := []Item{}
+
+	for _, item := range s.list(resource + "/lookup") {
+		if byID && item.has(id) {
+			found = append(found, item)
+		} else if data, _ := json.Marshal(item); !byID && strings.Contains(strings.ToLower(string(data)), term) {
+			found = append(found, item)
+		}
+	}
+
+	writeJSON(resp, http.StatusOK, found)
+}
+
+// has returns true if a field other than the item's id has a value.
+func (i Item) has(value string) bool {
+	for field, val := range i {
+		if field != "id" && strings.EqualFold(fmt.Sprint(val), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// editor applies the fields in a bulk edit to the items listed in the *Ids field, like the
+// movie, series, artist and author editors do. Items move to a new rootFolderPath.
+func (s *Server) editor(resp http.ResponseWriter, req *http.Request, resource string) {
+	edit, ok := readItem(resp, req)
+	if !ok {
+		return
+	}
+
+	ids, applyTags := []any{}, edit["applyTags"]
+
+	for field, value := range edit {
+		if strings.HasSuffix(field, "Ids") {
+			ids, _ = value.([]any)
+			delete(edit, field)
+		}
+	}
+
+	delete(edit, "applyTags")
+	delete(edit, "moveFiles")
+
+	updated := []Item{}
+
+	for _, id := range ids {
+		item, ok := s.items[resource][Item{"id": id}.ID()]
+		if !ok {
+			continue
+		}
+
+		for field, value := range edit {
+			switch field {
+			case "tags":
+				item[field] = editTags(item[field], value, applyTags)
+			case "rootFolderPath":
+				folder, _ := item["path"].(string)
+				item["path"] = strings.TrimSuffix(value.(string), "/") + "/" + path.Base(folder) //nolint:forcetypeassert
+				item[field] = value
+			default:
+				item[field] = value
+			}
+		}
+
+		updated = append(updated, item)
+	}
+
+	writeJSON(resp, http.StatusAccepted, updated)
+}
+
+// editTags adds, removes or replaces tags like the editors do.
+func editTags(current, tags, apply any) []any {
+	have, list := jsonList(current), jsonList(tags)
+
+	switch apply {
+	case "add":
+		for _, tag := range list {
+			if !slices.Contains(have, tag) {
+				have = append(have, tag)
+			}
+		}
+
+		return have
+	case "remove":
+		return slices.DeleteFunc(slices.Clone(have), func(tag any) bool { return slices.Contains(list, tag) })
+	default:
+		return list
+	}
+}
+
+// jsonList returns a list the way it decodes from json, so seeded lists compare with requests.
+func jsonList(list any) []any {
+	data, _ := json.Marshal(list)
+	decoded := []any{}
+	_ = json.Unmarshal(data, &decoded)
+
+	return decoded
+}
+
+// test fails like a starr app does, with a validation error, when the item is named FailName.
+func (s *Server) test(resp http.ResponseWriter, req *http.Request) {
+	item, ok := readItem(resp, req)
+	if !ok {
+		return
+	}
+
+	if item["name"] == FailName {
+		writeJSON(resp, http.StatusBadRequest, []map[string]any{{
+			"propertyName": "",
+			"errorMessage": "Test was aborted due to an error: connection refused",
+			"severity":     "error",
+		}})
+
+		return
+	}
+
+	writeJSON(resp, http.StatusOK, map[string]any{})
+}
+
+// page returns a paged list, like the block list.
+func (s *Server) page(resp http.ResponseWriter, req *http.Request, resource string) {
+	list := s.list(resource)
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(req.URL.Query().Get("pageSize"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	if size < 1 {
+		size = 10
+	}
+
+	start := min((page-1)*size, len(list))
+	end := min(start+size, len(list))
+
+	writeJSON(resp, http.StatusOK, map[string]any{
+		"page":          page,
+		"pageSize":      size,
+		"sortKey":       req.URL.Query().Get("sortKey"),
+		"sortDirection": req.URL.Query().Get("sortDirection"),
+		"totalRecords":  len(list),
+		"records":       list[start:end],
+	})
+}
+
+func (s *Server) status() map[string]any {
+	return map[string]any{
+		"appName":      string(s.App),
+		"instanceName": string(s.App),
+		"version":      Version,
+		"branch":       "main",
+		"isDocker":     true,
+		"osName":       "ubuntu",
+		"startTime":    "2020-01-01T00:00:00Z",
+	}
+}
+
+func (s *Server) initializeJS(resp http.ResponseWriter) {
+	resp.Header().Set("Content-Type", "application/javascript")
+	fmt.Fprintf(resp, "window.%s = {\n  apiRoot: '/api/%s',\n  apiKey: '%s',\n  release: '%s-main',\n"+
+		"  version: '%s',\n  instanceName: '%s',\n  theme: 'auto',\n  branch: 'main',\n  urlBase: ''\n};\n",
+		s.App, APIVersion(s.App), APIKey, Version, Version, s.App)
+}
+
+func readItem(resp http.ResponseWriter, req *http.Request) (Item, bool) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeJSON(resp, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return nil, false
+	}
+
+	item := Item{}
+	if err := json.Unmarshal(body, &item); err != nil {
+		writeJSON(resp, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return nil, false
+	}
+
+	return item, true
+}
+
+func writeJSON(resp http.ResponseWriter, code int, data any) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	_ = json.NewEncoder(resp).Encode(data)
+}
+
+// URL returns the base url for a starrs.AppConfig, with a trailing slash.
+func (s *Server) URL() string {
+	return s.Server.URL + "/"
+}
@@ -1,6 +1,7 @@
 package starrs
 
 import (
+	"errors"
 	"fmt"
 
 	"golift.io/starr"
@@ -19,7 +20,7 @@ func (s *Starrs) Tags(config *AppConfig) (map[int]string, error) {
 		msg := s.log.Translate("Getting tags: %v", err.Error())
 		s.log.Wails.Error(msg)
 
-		return nil, fmt.Errorf(msg)
+		return nil, errors.New(msg)
 	}
 
 	tagmap := make(map[int]string, len(tags))
@@ -54,3 +55,27 @@ func (s *Starrs) tags(config *AppConfig) ([]*starr.Tag, error) {
 		return nil, fmt.Errorf("%w: missing app", starr.ErrRequestError)
 	}
 }
+
+func (s *Starrs) addTag(config *AppConfig, label string) (*starr.Tag, error) {
+	instance, err := s.newAPIinstance(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch starr.App(config.App) {
+	case starr.Lidarr:
+		return lidarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	case starr.Prowlarr:
+		return prowlarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	case starr.Radarr:
+		return radarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	case starr.Readarr:
+		return readarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	case starr.Sonarr:
+		return sonarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	case starr.Whisparr:
+		return sonarr.New(instance.Config).AddTagContext(s.ctx, &starr.Tag{Label: label})
+	default:
+		return nil, fmt.Errorf("%w: missing app", starr.ErrRequestError)
+	}
+}